@@ -0,0 +1,957 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/storage"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func runAdd(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(AddCmd, flag.ExitOnError)
+	desc := flagSet.String("desc", "", "Task description, may contain +tag and project:name words")
+	due := flagSet.String("due", "", "Due date: YYYY-MM-DD, today, tomorrow, a weekday, next fri, +3d, ...")
+	scheduled := flagSet.String("scheduled", "", "Date to start working on the task, same formats as -due")
+	priority := flagSet.String("priority", "", "Priority: H, M, L or a number from 0 (none) to 9 (highest)")
+	parent := flagSet.Int("parent", -1, "Id of the task this one is a subtask of")
+	depends := flagSet.String("depends", "", "Comma-separated ids of the tasks that have to be completed first")
+	recur := flagSet.String("recur", "", "Repeat rule: daily, weekly, monthly, yearly, every 2 weeks, mon,thu, ... optionally \"after completion\"")
+	estimate := flagSet.String("estimate", "", "Expected effort: hours such as 4h or 1h30m, or story points such as 5pt")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	text, tags, project, err := todo.ParseDescription(*desc)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		return errors.New("description is required")
+	}
+	task := todo.Task{Description: text, Tags: tags, Project: project}
+	if task.Priority, err = todo.ParsePriority(*priority); err != nil {
+		return err
+	}
+	if task.Due, err = parseDateFlag("due", *due); err != nil {
+		return err
+	}
+	if task.Scheduled, err = parseDateFlag("scheduled", *scheduled); err != nil {
+		return err
+	}
+	if *depends != "" {
+		if task.DependsOn, err = todo.ParseIDList(*depends); err != nil {
+			return fmt.Errorf("invalid depends: %w", err)
+		}
+		slices.Sort(task.DependsOn)
+	}
+	if *recur != "" {
+		rule, err := todo.ParseRecurrence(*recur)
+		if err != nil {
+			return err
+		}
+		task.Recurrence = &rule
+	}
+	if *estimate != "" {
+		parsed, err := todo.ParseEstimate(*estimate)
+		if err != nil {
+			return err
+		}
+		task.Estimate = &parsed
+	}
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		if *parent >= 0 {
+			if err := todo.ValidateParent(list.Tasks, list.NextID, *parent); err != nil {
+				return list, err
+			}
+			task.Parent = parent
+		}
+		if err := todo.ValidateDependencies(list.Tasks, list.NextID, task.DependsOn); err != nil {
+			return list, err
+		}
+		updatedList := todo.Add(list, task)
+		fmt.Printf("Successfully added:\n%v\n", updatedList.Tasks[len(updatedList.Tasks)-1])
+		return updatedList, nil
+	})
+}
+
+func runList(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(ListCmd, flag.ExitOnError)
+	filter := flagSet.String("filter", string(todo.FilterAll), fmt.Sprintf("One of: %s", filterNames()))
+	sort := flagSet.String(
+		"sort", "",
+		fmt.Sprintf("Comma-separated sort keys, prefix with - to reverse, e.g. priority,-due. Keys: %s", strings.Join(todo.SortFields(), ", ")),
+	)
+	tag := flagSet.String("tag", "", "Only tasks carrying all of these comma-separated tags")
+	project := flagSet.String("project", "", "Only tasks in this project or its sub-projects")
+	where := flagSet.String("where", "", `Query, e.g. 'done:false and (tag:work or priority>=5) and due<+7d and desc~"deploy"'`)
+	since := flagSet.String("since", "", "Only tasks whose -timestamp is on or after this date, e.g. monday, -7d, 2026-10-01")
+	until := flagSet.String("until", "", "Only tasks whose -timestamp is on or before this date")
+	timestamp := flagSet.String("timestamp", "created", "Timestamp -since and -until apply to: created, updated, completed or archived")
+	flat := flagSet.Bool("flat", false, "Print a plain list instead of nesting subtasks under their parents")
+	archived := flagSet.Bool("archived", false, "List the archived tasks instead of the active ones")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	getTimestamp, ok := todo.TimestampFields[*timestamp]
+	if !ok {
+		return fmt.Errorf("invalid timestamp value: %s", *timestamp)
+	}
+	sinceTime, untilTime, err := parsePeriod(*since, *until)
+	if err != nil {
+		return err
+	}
+	query, err := todo.ParseQuery(*where)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	if !slices.Contains(todo.Filters(), todo.TaskStateFilter(*filter)) {
+		return fmt.Errorf("invalid filter value: %s", *filter)
+	}
+	sortKeys, err := todo.ParseSort(*sort)
+	if err != nil {
+		return err
+	}
+	requiredTags, err := todo.NormalizeTags(splitList(*tag))
+	if err != nil {
+		return err
+	}
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	tasks := list.Tasks
+	if *archived {
+		tasks = list.Archived
+	}
+	filteredTasks := todo.Filter(todo.List(tasks, *filter), func(task todo.Task) bool {
+		for _, requiredTag := range requiredTags {
+			if !todo.HasTag(task, requiredTag) {
+				return false
+			}
+		}
+		return (*project == "" || todo.InProject(task, *project)) &&
+			todo.InPeriod(getTimestamp(task), sinceTime, untilTime) &&
+			query.Match(task)
+	})
+	todo.Sort(filteredTasks, sortKeys...)
+	if *flat {
+		for _, task := range filteredTasks {
+			fmt.Println(task)
+		}
+		return nil
+	}
+	for _, node := range todo.Tree(filteredTasks, tasks) {
+		progress := ""
+		if node.Total > 0 {
+			progress = fmt.Sprintf(" [%d/%d]", node.Done, node.Total)
+		}
+		fmt.Printf("%s%v%s\n", strings.Repeat("  ", node.Depth), node.Task, progress)
+	}
+	return nil
+}
+
+func runTags(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(TagsCmd, flag.ExitOnError)
+	filter := flagSet.String("filter", string(todo.FilterPending), fmt.Sprintf("Count tags of these tasks only. One of: %s", filterNames()))
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains(todo.Filters(), todo.TaskStateFilter(*filter)) {
+		return fmt.Errorf("invalid filter value: %s", *filter)
+	}
+	tasks, err := store.List(*filter)
+	if err != nil {
+		return err
+	}
+	counts := todo.TagCounts(tasks)
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		fmt.Printf("%s%s %d\n", todo.TagPrefix, tag, counts[tag])
+	}
+	return nil
+}
+
+func runComplete(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(CompleteCmd, flag.ExitOnError)
+	return runBulk(store, flagSet, "complete", "Completed", todo.CompleteMany, args)
+}
+
+func runCancel(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(CancelCmd, flag.ExitOnError)
+	cancelMany := func(list todo.TaskList, ids []int) (todo.TaskList, todo.BulkResult) {
+		return todo.SetStatusMany(list, ids, todo.StatusCancelled)
+	}
+	return runBulk(store, flagSet, "cancel", "Cancelled", cancelMany, args)
+}
+
+// runStart moves the selected tasks to in-progress. A single selected task
+// also gets its timer started, as only one timer runs at a time.
+func runStart(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(StartCmd, flag.ExitOnError)
+	timer := flagSet.Bool("timer", true, "Start the timer too when a single task is selected")
+	return runBulk(store, flagSet, "start", "Started", func(list todo.TaskList, ids []int) (todo.TaskList, todo.BulkResult) {
+		if *timer && len(ids) == 1 {
+			tasks, err := todo.StartTimer(slices.Clone(list.Tasks), ids[0])
+			if err == nil {
+				list.Tasks = tasks
+				return list, todo.BulkResult{Affected: ids}
+			}
+			fmt.Printf("Timer not started: %v\n", err)
+		}
+		return todo.SetStatusMany(list, ids, todo.StatusInProgress)
+	}, args)
+}
+
+func runStop(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(StopCmd, flag.ExitOnError)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		updatedTasks, task, err := todo.StopTimer(list.Tasks)
+		if err != nil {
+			return list, err
+		}
+		list.Tasks = updatedTasks
+		last := task.Intervals[len(task.Intervals)-1]
+		fmt.Printf("Stopped the timer after %s:\n%v\n", todo.FormatDuration(last.Duration(todo.Now())), task)
+		return list, nil
+	})
+}
+
+func runLog(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(LogCmd, flag.ExitOnError)
+	id := flagSet.Int("id", -1, "Task id")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *id == -1 {
+		return errors.New("id is required")
+	}
+	if flagSet.NArg() != 1 {
+		return errors.New("expected a single duration, e.g. log --id 4 1h30m")
+	}
+	duration, err := todo.ParseDuration(flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		updatedTasks, err := todo.LogTime(list.Tasks, *id, duration)
+		if err != nil {
+			return list, err
+		}
+		list.Tasks = updatedTasks
+		fmt.Printf("Logged %s on task %d\n", todo.FormatDuration(duration), *id)
+		return list, nil
+	})
+}
+
+// runReport prints a summary of the given kind; time is the only one so far.
+func runReport(store storage.Store, args []string) error {
+	if len(args) == 0 || args[0] != "time" {
+		return errors.New("unknown report, expected: report time [flags]")
+	}
+	flagSet := flag.NewFlagSet(ReportCmd+" time", flag.ExitOnError)
+	since := flagSet.String("since", "", "Only time logged on or after this date, e.g. monday, -7d, 2026-10-01")
+	until := flagSet.String("until", "", "Only time logged on or before this date")
+	by := flagSet.String("by", string(todo.ReportByProject), "Sum the time by project, tag, task or day")
+	where := flagSet.String("where", "", "Only tasks matching this query")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return err
+	}
+	group, err := todo.ParseReportGroup(*by)
+	if err != nil {
+		return err
+	}
+	sinceTime, untilTime, err := parsePeriod(*since, *until)
+	if err != nil {
+		return err
+	}
+	query, err := todo.ParseQuery(*where)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	// archived work still counts towards the time spent
+	tasks := todo.Filter(append(list.Tasks, list.Archived...), query.Match)
+	lines := todo.TimeReport(tasks, group, sinceTime, untilTime)
+	// summed over tasks, as a task with several tags shows under each of them
+	var total time.Duration
+	for _, task := range tasks {
+		total += todo.Logged(task, sinceTime, untilTime, todo.Now())
+	}
+	width := len("Total")
+	for _, line := range lines {
+		width = max(width, len(line.Key))
+	}
+	for _, line := range lines {
+		fmt.Printf("%-*s  %s\n", width, line.Key, todo.FormatDuration(line.Duration))
+	}
+	fmt.Printf("%-*s  %s\n", width, "Total", todo.FormatDuration(total))
+	return nil
+}
+
+func runDelete(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(DeleteCmd, flag.ExitOnError)
+	policy := todo.ChildrenOrphan
+	flagSet.Func("children", "What happens to subtasks: orphan (keep them as top-level tasks, default) or cascade (delete them too)", func(value string) error {
+		var err error
+		policy, err = todo.ParseChildPolicy(value)
+		return err
+	})
+	deleteMany := func(list todo.TaskList, ids []int) (todo.TaskList, todo.BulkResult) {
+		var result todo.BulkResult
+		list.Tasks, result = todo.DeleteMany(list.Tasks, ids, policy)
+		return list, result
+	}
+	return runBulk(store, flagSet, "delete", "Deleted", deleteMany, args)
+}
+
+func runArchive(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(ArchiveCmd, flag.ExitOnError)
+	return runBulk(store, flagSet, "archive", "Archived", todo.ArchiveMany, args)
+}
+
+func runRestore(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(RestoreCmd, flag.ExitOnError)
+	archived := func(list todo.TaskList) []todo.Task { return list.Archived }
+	return runBulkOn(store, flagSet, "restore", "Restored", archived, todo.RestoreMany, args)
+}
+
+func runPurge(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(PurgeCmd, flag.ExitOnError)
+	olderThan := flagSet.String("older-than", "", "Purge the tasks archived longer ago than this, e.g. 90d, 12w, 6m or 1y")
+	dryRun := flagSet.Bool("dry-run", false, "Only print what would be purged")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *olderThan == "" {
+		return errors.New("older-than is required")
+	}
+	cutoff, err := todo.ParseAge(*olderThan, todo.Now())
+	if err != nil {
+		return err
+	}
+	run, pastVerb := update, "Purged"
+	if *dryRun {
+		run, pastVerb = preview, "Would purge"
+	}
+	return run(store, func(list todo.TaskList) (todo.TaskList, error) {
+		before := list.Archived
+		list, purged := todo.Purge(list, cutoff)
+		fmt.Printf("%s %d task(s) archived before %s\n", pastVerb, len(purged), cutoff.Format(todo.DateLayout))
+		for _, task := range before {
+			if slices.Contains(purged, task.ID) {
+				fmt.Println(task)
+			}
+		}
+		return list, nil
+	})
+}
+
+// runBulk applies a multi-id operation to the active tasks picked by -id
+// and/or -where in a single load/save cycle and reports what it did. flagSet
+// may carry command specific flags already.
+func runBulk(
+	store storage.Store, flagSet *flag.FlagSet, verb string, pastVerb string,
+	apply func(todo.TaskList, []int) (todo.TaskList, todo.BulkResult), args []string,
+) error {
+	active := func(list todo.TaskList) []todo.Task { return list.Tasks }
+	return runBulkOn(store, flagSet, verb, pastVerb, active, apply, args)
+}
+
+// runBulkOn is runBulk picking the tasks from those returned by tasks.
+func runBulkOn(
+	store storage.Store, flagSet *flag.FlagSet, verb string, pastVerb string,
+	tasks func(todo.TaskList) []todo.Task, apply func(todo.TaskList, []int) (todo.TaskList, todo.BulkResult), args []string,
+) error {
+	id := flagSet.String("id", "", fmt.Sprintf("Ids to %s: comma-separated ids and ranges, e.g. 3,5,7-12", verb))
+	where := flagSet.String("where", "", fmt.Sprintf("Query selecting the tasks to %s; combined with -id only tasks matching both are used", verb))
+	dryRun := flagSet.Bool("dry-run", false, "Only print what would change")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" && *where == "" {
+		return errors.New("id or where is required")
+	}
+	run := update
+	if *dryRun {
+		run = preview
+		pastVerb = "Would " + verb
+	}
+	return run(store, func(list todo.TaskList) (todo.TaskList, error) {
+		ids, err := selectIDs(tasks(list), *id, *where)
+		if err != nil {
+			return list, err
+		}
+		before := slices.Clone(tasks(list)) // apply may modify the tasks in place
+		updatedList, result := apply(list, ids)
+		after := map[int]todo.Task{}
+		for _, task := range append(slices.Clone(updatedList.Tasks), updatedList.Archived...) {
+			after[task.ID] = task
+		}
+		fmt.Printf("%s %d task(s)\n", pastVerb, len(result.Affected))
+		for _, task := range before {
+			if !slices.Contains(result.Affected, task.ID) {
+				continue
+			}
+			if updatedTask, ok := after[task.ID]; ok {
+				task = updatedTask
+			}
+			fmt.Println(task)
+		}
+		for _, id := range result.Created {
+			fmt.Printf("Next occurrence: %v\n", after[id])
+		}
+		if len(result.Missing) > 0 {
+			fmt.Printf("Missing ids: %s\n", joinIDs(result.Missing))
+		}
+		if len(result.Blocked) > 0 {
+			fmt.Printf("Blocked by open subtasks: %s\n", joinIDs(result.Blocked))
+		}
+		if len(result.Rejected) > 0 {
+			fmt.Printf("Not allowed by the workflow: %s\n", joinIDs(result.Rejected))
+		}
+		if len(result.Affected) == 0 && len(result.Missing)+len(result.Blocked)+len(result.Rejected) > 0 {
+			return list, fmt.Errorf("could not %s any of the requested tasks", verb)
+		}
+		return updatedList, nil
+	})
+}
+
+func runEdit(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(EditCmd, flag.ExitOnError)
+	id := flagSet.Int("id", -1, "Task id")
+	desc := flagSet.String("desc", "", "New description; +tag and project:name words are applied as with add")
+	due := flagSet.String("due", "", "New due date, \"none\" clears it")
+	scheduled := flagSet.String("scheduled", "", "New scheduled date, \"none\" clears it")
+	priority := flagSet.String("priority", "", "New priority: H, M, L or 0-9, 0 clears it")
+	project := flagSet.String("project", "", "New project, an empty value clears it")
+	tag := flagSet.String("tag", "", "Comma-separated tag changes: +name or name adds, -name removes")
+	parent := flagSet.String("parent", "", "Id of the new parent task, \"none\" makes it a top-level task")
+	depends := flagSet.String("depends", "", "Comma-separated dependency changes: +id or id adds, -id removes")
+	recur := flagSet.String("recur", "", "New repeat rule, same formats as with add, \"none\" stops the repetition")
+	estimate := flagSet.String("estimate", "", "New estimate, same formats as with add, \"none\" clears it")
+	notes := flagSet.Bool("notes", false, "Edit the markdown notes of the task in $VISUAL or $EDITOR")
+	status := flagSet.String("status", "", "New status, one of the workflow statuses")
+	reopen := flagSet.Bool("reopen", false, "Move a closed task back to the initial status of the workflow")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *id == -1 {
+		return errors.New("id is required")
+	}
+	isSet := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) { isSet[f.Name] = true })
+
+	patch := todo.Patch{}
+	if isSet["desc"] {
+		text, tags, descProject, err := todo.ParseDescription(*desc)
+		if err != nil {
+			return err
+		}
+		patch.Description = &text
+		patch.AddTags = tags
+		if descProject != "" {
+			patch.Project = &descProject
+		}
+	}
+	if isSet["due"] {
+		date, err := parseClearableDateFlag("due", *due)
+		if err != nil {
+			return err
+		}
+		patch.Due = &date
+	}
+	if isSet["scheduled"] {
+		date, err := parseClearableDateFlag("scheduled", *scheduled)
+		if err != nil {
+			return err
+		}
+		patch.Scheduled = &date
+	}
+	if isSet["priority"] {
+		parsed, err := todo.ParsePriority(*priority)
+		if err != nil {
+			return err
+		}
+		patch.Priority = &parsed
+	}
+	if isSet["project"] {
+		patch.Project = project
+	}
+	for _, change := range splitList(*tag) {
+		if removed, ok := strings.CutPrefix(change, "-"); ok {
+			patch.RemoveTags = append(patch.RemoveTags, removed)
+		} else {
+			patch.AddTags = append(patch.AddTags, change)
+		}
+	}
+	if isSet["parent"] {
+		parentID := -1
+		if !strings.EqualFold(*parent, "none") {
+			var err error
+			if parentID, err = strconv.Atoi(*parent); err != nil || parentID < 0 {
+				return fmt.Errorf("invalid parent: %s", *parent)
+			}
+		}
+		patch.Parent = &parentID
+	}
+	for _, change := range splitList(*depends) {
+		removed, isRemoval := strings.CutPrefix(change, "-")
+		dependency, err := strconv.Atoi(strings.TrimPrefix(removed, "+"))
+		if err != nil || dependency < 0 {
+			return fmt.Errorf("invalid depends: %s", change)
+		}
+		if isRemoval {
+			patch.RemoveDependencies = append(patch.RemoveDependencies, dependency)
+		} else {
+			patch.AddDependencies = append(patch.AddDependencies, dependency)
+		}
+	}
+	if isSet["recur"] {
+		rule := todo.Recurrence{}
+		if !strings.EqualFold(*recur, "none") {
+			var err error
+			if rule, err = todo.ParseRecurrence(*recur); err != nil {
+				return err
+			}
+		}
+		patch.Recurrence = &rule
+	}
+	if isSet["estimate"] {
+		parsed := todo.Estimate{}
+		if !strings.EqualFold(*estimate, "none") {
+			var err error
+			if parsed, err = todo.ParseEstimate(*estimate); err != nil {
+				return err
+			}
+		}
+		patch.Estimate = &parsed
+	}
+	if *notes {
+		// the editor runs before the store is locked, the patch only replaces the notes
+		task, err := store.Get(*id)
+		if err != nil {
+			return err
+		}
+		text, err := editText(task.Notes)
+		if err != nil {
+			return err
+		}
+		patch.Notes = &text
+	}
+	if isSet["status"] {
+		parsed, err := todo.ParseStatus(*status)
+		if err != nil {
+			return err
+		}
+		patch.Status = &parsed
+	}
+	if *reopen {
+		initial := todo.ActiveWorkflow.Initial()
+		patch.Status = &initial
+	}
+	if len(isSet) == 1 {
+		return errors.New("nothing to change, pass at least one field flag")
+	}
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		updatedTasks, err := todo.Update(list.Tasks, *id, patch)
+		if err != nil {
+			return list, err
+		}
+		list.Tasks = updatedTasks
+		for _, task := range list.Tasks {
+			if task.ID == *id {
+				fmt.Printf("Successfully updated:\n%v\n", task)
+			}
+		}
+		return list, nil
+	})
+}
+
+func runAnnotate(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(AnnotateCmd, flag.ExitOnError)
+	id := flagSet.Int("id", -1, "Task id")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *id == -1 {
+		return errors.New("id is required")
+	}
+	text := strings.Join(flagSet.Args(), " ")
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		updatedTasks, err := todo.Annotate(list.Tasks, *id, text)
+		if err != nil {
+			return list, err
+		}
+		list.Tasks = updatedTasks
+		fmt.Printf("Successfully annotated task %d\n", *id)
+		return list, nil
+	})
+}
+
+func runShow(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(ShowCmd, flag.ExitOnError)
+	id := flagSet.Int("id", -1, "Task id")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *id == -1 {
+		return errors.New("id is required")
+	}
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	// archived tasks can be shown too
+	found := todo.Filter(append(list.Tasks, list.Archived...), func(task todo.Task) bool { return task.ID == *id })
+	if len(found) == 0 {
+		return fmt.Errorf("task with requested id=%d is missing", *id)
+	}
+	fmt.Print(found[0].Detail())
+	return nil
+}
+
+func runPlan(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(PlanCmd, flag.ExitOnError)
+	capacity := flagSet.String("capacity", "", "Effort available a day, e.g. 6h or 8pt; only tasks estimated in the same unit are planned")
+	from := flagSet.String("from", "today", "First day of the plan, same formats as -due")
+	where := flagSet.String("where", "", "Plan only the tasks matching this query")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *capacity == "" {
+		return errors.New("capacity is required")
+	}
+	daily, err := todo.ParseEstimate(*capacity)
+	if err != nil {
+		return fmt.Errorf("invalid capacity: %w", err)
+	}
+	start, err := parseDateFlag("from", *from)
+	if err != nil {
+		return err
+	}
+	query, err := todo.ParseQuery(*where)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	list, err := store.Load()
+	if err != nil {
+		return err
+	}
+	plan := todo.MakePlan(todo.Filter(list.Tasks, query.Match), daily, *start)
+	fmt.Printf("Plan from %s at %s a day:\n", plan.From.Format(todo.DateLayout), plan.Capacity)
+	late := []int{}
+	for _, entry := range plan.Entries {
+		days := entry.Start.Format(todo.DateLayout)
+		if !entry.Finish.Equal(entry.Start) {
+			days += " - " + entry.Finish.Format(todo.DateLayout)
+		}
+		remaining := todo.Estimate{Amount: entry.Remaining, Unit: daily.Unit}
+		fmt.Printf("%-23s %v, %s left\n", days, entry.Task, remaining)
+		if entry.Late() {
+			late = append(late, entry.Task.ID)
+		}
+	}
+	if len(late) > 0 {
+		fmt.Printf("Will miss their due date: %s\n", joinIDs(late))
+	}
+	if len(plan.Unestimated) > 0 {
+		ids := make([]int, len(plan.Unestimated))
+		for i, task := range plan.Unestimated {
+			ids[i] = task.ID
+		}
+		unit := "hours"
+		if daily.Unit == todo.Points {
+			unit = "points"
+		}
+		fmt.Printf("Warning: not planned for lack of an estimate in %s: %s\n", unit, joinIDs(ids))
+	}
+	return nil
+}
+
+func runExport(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(ExportCmd, flag.ExitOnError)
+	format := flagSet.String("format", "", "Output format, one of the store formats")
+	out := flagSet.String("out", "", "Output filepath")
+	where := flagSet.String("where", "", "Export only the tasks matching this query")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" || *out == "" {
+		return errors.New("both format and out flags are required")
+	}
+	query, err := todo.ParseQuery(*where)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	target, err := storage.OpenFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	list.Tasks = todo.Filter(list.Tasks, query.Match)
+	list.Archived = todo.Filter(list.Archived, query.Match)
+	return target.Save(list)
+}
+
+func runLoad(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(LoadCmd, flag.ExitOnError)
+	file := flagSet.String("file", "", "Filepath or <format>://<path> URI to import")
+	format := flagSet.String("format", "", "Format of the file, by default picked by its extension")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("file is required")
+	}
+	var source storage.Store
+	var err error
+	if *format != "" {
+		source, err = storage.OpenFormat(*format, *file)
+	} else {
+		source, err = storage.Open(*file)
+	}
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return update(store, func(list todo.TaskList) (todo.TaskList, error) {
+		// tasks loaded from Taskwarrior again keep their ids
+		if taskwarrior, ok := source.(*storage.TaskwarriorStore); ok {
+			for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+				if task.UUID != "" {
+					taskwarrior.Known[task.UUID] = task.ID
+				}
+			}
+		}
+		loadedList, err := source.Load()
+		if err != nil {
+			return list, err
+		}
+		// never hand out an id this store has already used
+		loadedList.NextID = max(loadedList.NextID, list.NextID)
+		return loadedList, nil
+	})
+}
+
+func runUndo(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(UndoCmd, flag.ExitOnError)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	count := 1
+	if flagSet.NArg() > 0 {
+		var err error
+		if count, err = strconv.Atoi(flagSet.Arg(0)); err != nil || count < 1 {
+			return fmt.Errorf("invalid number of operations to undo: %s", flagSet.Arg(0))
+		}
+	}
+	return updateJournal(store, func(list todo.TaskList, journal todo.Journal) (todo.TaskList, todo.Journal, error) {
+		list, journal, undone, err := journal.Undo(list, count)
+		if err != nil {
+			return list, journal, err
+		}
+		for _, operation := range undone {
+			fmt.Printf("Undid %q (%s)\n", operation.Command, operation.Summary())
+		}
+		return list, journal, nil
+	})
+}
+
+func runRedo(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(RedoCmd, flag.ExitOnError)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	return updateJournal(store, func(list todo.TaskList, journal todo.Journal) (todo.TaskList, todo.Journal, error) {
+		list, journal, redone, err := journal.Redo(list)
+		if err != nil {
+			return list, journal, err
+		}
+		fmt.Printf("Redid %q (%s)\n", redone.Command, redone.Summary())
+		return list, journal, nil
+	})
+}
+
+func runHistory(store storage.Store, args []string) error {
+	flagSet := flag.NewFlagSet(HistoryCmd, flag.ExitOnError)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	journal, err := storage.LoadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+	for i, operation := range journal.Operations {
+		undone := ""
+		if operation.Undone {
+			undone = " [undone]"
+		}
+		fmt.Printf("%3d. %s  %s (%s)%s\n",
+			i+1, operation.Time.Local().Format(todo.AnnotationLayout), operation.Command, operation.Summary(), undone)
+	}
+	return nil
+}
+
+func filterNames() string {
+	names := []string{}
+	for _, filter := range todo.Filters() {
+		names = append(names, string(filter))
+	}
+	return strings.Join(names, ", ")
+}
+
+// parsePeriod turns -since/-until flags into [since, until) bounds; until is
+// inclusive of the given day. Weekday names refer to the past.
+func parsePeriod(since string, until string) (*time.Time, *time.Time, error) {
+	var sinceTime, untilTime *time.Time
+	now := todo.Now()
+	if since != "" {
+		date, err := todo.ParsePastDate(since, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid since: %w", err)
+		}
+		sinceTime = &date
+	}
+	if until != "" {
+		date, err := todo.ParsePastDate(until, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid until: %w", err)
+		}
+		date = date.AddDate(0, 0, 1)
+		untilTime = &date
+	}
+	return sinceTime, untilTime, nil
+}
+
+// parseClearableDateFlag maps "none" to the zero time, which a Patch treats as clearing the date.
+func parseClearableDateFlag(name string, value string) (time.Time, error) {
+	if strings.EqualFold(value, "none") {
+		return time.Time{}, nil
+	}
+	date, err := parseDateFlag(name, value)
+	if err != nil || date == nil {
+		return time.Time{}, err
+	}
+	return *date, nil
+}
+
+// parseDateFlag returns nil for an empty value so optional dates stay unset.
+func parseDateFlag(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := todo.ParseDate(value, todo.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &date, nil
+}
+
+// editText opens text in the user's editor and returns what was saved.
+func editText(text string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = DefaultEditor
+	}
+	file, err := os.CreateTemp("", "todo-notes-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a file for the editor: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write a file for the editor: %w", err)
+	}
+	// the editor may carry arguments, e.g. "code --wait"
+	command := strings.Fields(editor)
+	cmd := exec.Command(command[0], append(command[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read the edited notes: %w", err)
+	}
+	return string(edited), nil
+}
+
+// splitList turns "a, b,,c" into [a b c].
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// selectIDs resolves the tasks a bulk command applies to. With only -where
+// every matching task is selected; with -id the requested ids are returned
+// as given so that missing ones can be reported, filtered by -where when set.
+func selectIDs(tasks []todo.Task, id string, where string) ([]int, error) {
+	query, err := todo.ParseQuery(where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	if id == "" {
+		ids := []int{}
+		for _, task := range todo.Filter(tasks, query.Match) {
+			ids = append(ids, task.ID)
+		}
+		return ids, nil
+	}
+	ids, err := todo.ParseIDList(id)
+	if err != nil {
+		return nil, err
+	}
+	if where == "" {
+		return ids, nil
+	}
+	matching := map[int]bool{}
+	for _, task := range todo.Filter(tasks, query.Match) {
+		matching[task.ID] = true
+	}
+	existing := map[int]bool{}
+	for _, task := range tasks {
+		existing[task.ID] = true
+	}
+	// keep the ids missing altogether, drop the existing ones the query rejects
+	return slices.DeleteFunc(ids, func(id int) bool { return existing[id] && !matching[id] }), nil
+}
+
+func joinIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -5,150 +5,223 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/vladiakimenko/go_project_planner/internal/storage"
 	"github.com/vladiakimenko/go_project_planner/internal/todo"
 )
 
-const JsonStoragePath string = "tasks.json"
+const DefaultStore string = "tasks.json"
+
+// WorkflowEnv names the environment variable holding a custom workflow, see
+// todo.ParseWorkflow for its format.
+const WorkflowEnv string = "TODO_WORKFLOW"
+
+// DefaultEditor is used for notes when neither $VISUAL nor $EDITOR is set.
+const DefaultEditor string = "vi"
 
 const (
 	AddCmd      string = "add"
 	ListCmd     string = "list"
 	CompleteCmd string = "complete"
+	CancelCmd   string = "cancel"
+	StartCmd    string = "start"
+	StopCmd     string = "stop"
+	LogCmd      string = "log"
+	ReportCmd   string = "report"
+	PlanCmd     string = "plan"
 	DeleteCmd   string = "delete"
 	ExportCmd   string = "export"
 	LoadCmd     string = "load"
+	TagsCmd     string = "tags"
+	EditCmd     string = "edit"
+	AnnotateCmd string = "annotate"
+	ShowCmd     string = "show"
+	ArchiveCmd  string = "archive"
+	RestoreCmd  string = "restore"
+	PurgeCmd    string = "purge"
+	UndoCmd     string = "undo"
+	RedoCmd     string = "redo"
+	HistoryCmd  string = "history"
 )
 
-func main() {
-	var tasks []todo.Task
+var lockTimeout time.Duration
 
-	rawArgs := os.Args
-	if len(rawArgs) < 2 {
-		log.Fatal("No command provided")
-	}
-	command, args := rawArgs[1], rawArgs[2:]
+// archiveStore keeps the archived tasks apart from the task store when set.
+var archiveStore storage.Store
+
+// journalPath is where the changes made by commandLine are recorded.
+var journalPath, commandLine string
+
+var commands = map[string]func(storage.Store, []string) error{
+	AddCmd:      runAdd,
+	ListCmd:     runList,
+	CompleteCmd: runComplete,
+	CancelCmd:   runCancel,
+	StartCmd:    runStart,
+	StopCmd:     runStop,
+	LogCmd:      runLog,
+	ReportCmd:   runReport,
+	PlanCmd:     runPlan,
+	DeleteCmd:   runDelete,
+	ExportCmd:   runExport,
+	LoadCmd:     runLoad,
+	TagsCmd:     runTags,
+	EditCmd:     runEdit,
+	AnnotateCmd: runAnnotate,
+	ShowCmd:     runShow,
+	ArchiveCmd:  runArchive,
+	RestoreCmd:  runRestore,
+	PurgeCmd:    runPurge,
+	UndoCmd:     runUndo,
+	RedoCmd:     runRedo,
+	HistoryCmd:  runHistory,
+}
+
+func main() {
+	storeURI := flag.String(
+		"store", DefaultStore,
+		fmt.Sprintf("Task store, a path or a <format>://<path> URI. Formats: %s", strings.Join(storage.StoreFormats(), ", ")),
+	)
+	archiveURI := flag.String("archive", "", "Separate store for archived tasks, a path or URI; by default they stay in the task store")
+	flag.DurationVar(&lockTimeout, "lock-timeout", 5*time.Second, "How long to wait for another process to release the store")
+	flag.Parse()
 
-	if command != LoadCmd {
-		result, err := storage.LoadJSON(JsonStoragePath)
+	if spec := os.Getenv(WorkflowEnv); spec != "" {
+		workflow, err := todo.ParseWorkflow(spec)
 		if err != nil {
-			log.Fatal(err)
+			log.Fatalf("Invalid %s: %v", WorkflowEnv, err)
 		}
-		tasks = result
+		todo.ActiveWorkflow = workflow
 	}
 
-	switch command {
-	case AddCmd:
-		flagSet := flag.NewFlagSet(AddCmd, flag.ExitOnError)
-		desc := flagSet.String("desc", "", "Task description")
-		if err := flagSet.Parse(args); err != nil {
-			log.Fatal(err)
-		}
-		if *desc == "" {
-			log.Fatal("description is required")
-		}
-		updatedTasks := todo.Add(tasks, *desc)
-		fmt.Printf("Successfully added:\n%v\n", updatedTasks[len(updatedTasks)-1])
-		if err := storage.SaveJSON(JsonStoragePath, updatedTasks); err != nil {
-			log.Fatal(err)
-		}
-	case ListCmd:
-		flagSet := flag.NewFlagSet(ListCmd, flag.ExitOnError)
-		filter := flagSet.String(
-			"filter", string(todo.FilterAll),
-			fmt.Sprintf("One of: %s, %s, %s", todo.FilterAll, todo.FilterDone, todo.FilterPending),
-		)
-		if err := flagSet.Parse(args); err != nil {
-			log.Fatal(err)
-		}
-		if !slices.Contains([]string{string(todo.FilterAll), string(todo.FilterDone), string(todo.FilterPending)}, *filter) {
-			log.Fatalf("Invalid filter value: %s", *filter)
-		}
-		filteredTasks := todo.List(tasks, *filter)
-		for _, task := range filteredTasks {
-			fmt.Println(task)
-		}
-	case CompleteCmd:
-		flagSet := flag.NewFlagSet(CompleteCmd, flag.ExitOnError)
-		id := flagSet.Int("id", -1, "Task id")
-		if err := flagSet.Parse(args); err != nil {
-			log.Fatal(err)
-		}
-		if *id == -1 {
-			log.Fatal("id is required")
-		}
-		updatedTasks, err := todo.Complete(tasks, *id)
-		if err != nil {
-			log.Fatal(err)
-		}
-		if err := storage.SaveJSON(JsonStoragePath, updatedTasks); err != nil {
-			log.Fatal(err)
-		}
-	case DeleteCmd:
-		flagSet := flag.NewFlagSet(DeleteCmd, flag.ExitOnError)
-		id := flagSet.Int("id", -1, "Task id")
-		if err := flagSet.Parse(args); err != nil {
+	if flag.NArg() < 1 {
+		log.Fatal("No command provided")
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+	journalPath, commandLine = storage.JournalPath(*storeURI), strings.Join(flag.Args(), " ")
+	run, ok := commands[command]
+	if !ok {
+		log.Fatalf("Unknown command: %s", command)
+	}
+
+	store, err := storage.OpenStore(*storeURI)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *archiveURI != "" {
+		if archiveStore, err = storage.OpenStore(*archiveURI); err != nil {
 			log.Fatal(err)
 		}
-		if *id == -1 {
-			log.Fatal("id is required")
+	}
+	err = run(store, args)
+	if closeErr := store.Close(); err == nil {
+		err = closeErr
+	}
+	if archiveStore != nil {
+		if closeErr := archiveStore.Close(); err == nil {
+			err = closeErr
 		}
-		updatedTasks, err := todo.Delete(tasks, *id)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("done")
+	os.Exit(0)
+
+}
+
+// update runs a load-modify-save cycle and records the changes it made in
+// the journal.
+func update(store storage.Store, modify func(todo.TaskList) (todo.TaskList, error)) error {
+	return updateJournal(store, func(list todo.TaskList, journal todo.Journal) (todo.TaskList, todo.Journal, error) {
+		before := list
+		// modify may change the tasks in place
+		before.Tasks, before.Archived = slices.Clone(list.Tasks), slices.Clone(list.Archived)
+		updatedList, err := modify(list)
 		if err != nil {
-			log.Fatal(err)
-		}
-		if err := storage.SaveJSON(JsonStoragePath, updatedTasks); err != nil {
-			log.Fatal(err)
-		}
-	case ExportCmd:
-		flagSet := flag.NewFlagSet(ExportCmd, flag.ExitOnError)
-		format := flagSet.String("format", "", "Output format: json or csv")
-		out := flagSet.String("out", "", "Output filepath")
-		if err := flagSet.Parse(args); err != nil {
-			log.Fatal(err)
-		}
-		if *format == "" || *out == "" {
-			log.Fatal("both format and out flags are required")
+			return list, journal, err
 		}
-		var action func(string, []todo.Task) error
-		switch *format {
-		case "csv":
-			action = storage.SaveCSV
-		case "json":
-			action = storage.SaveJSON
-		default:
-			log.Fatalf("incorrect format value provided: %s", *format)
+		if changes := todo.Diff(before, updatedList); len(changes) > 0 {
+			journal = journal.Record(todo.Operation{Time: todo.Now(), Command: commandLine, Changes: changes})
 		}
-		if err := action(*out, tasks); err != nil {
-			log.Fatal(err)
-		}
-	case LoadCmd:
-		flagSet := flag.NewFlagSet(LoadCmd, flag.ExitOnError)
-		file := flagSet.String("file", "", "Filepath to import")
-		if err := flagSet.Parse(args); err != nil {
-			log.Fatal(err)
-		}
-		var action func(string) ([]todo.Task, error)
-		switch filepath.Ext(*file) {
-		case ".json":
-			action = storage.LoadJSON
-		case ".csv":
-			action = storage.LoadCSV
-		default:
-			log.Fatal("the output file must be either .json or .csv")
-		}
-		updatedTasks, err := action(*file)
+		return updatedList, journal, nil
+	})
+}
+
+// updateJournal runs a load-modify-save cycle over the tasks and the journal
+// while holding the store lock, and the archive store lock when one is set.
+func updateJournal(
+	store storage.Store, modify func(todo.TaskList, todo.Journal) (todo.TaskList, todo.Journal, error),
+) error {
+	unlock, err := store.Lock(lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if archiveStore != nil {
+		unlockArchive, err := archiveStore.Lock(lockTimeout)
 		if err != nil {
-			log.Fatal(err)
-		}
-		if err := storage.SaveJSON(JsonStoragePath, updatedTasks); err != nil {
-			log.Fatal(err)
+			return err
 		}
+		defer unlockArchive()
 	}
-	fmt.Println("done")
-	os.Exit(0)
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	journal, err := storage.LoadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+	updatedList, updatedJournal, err := modify(list, journal)
+	if err != nil {
+		return err
+	}
+	if err := save(store, updatedList); err != nil {
+		return err
+	}
+	return storage.SaveJournal(journalPath, updatedJournal)
+}
 
+// preview runs modify against the current tasks without saving the result.
+func preview(store storage.Store, modify func(todo.TaskList) (todo.TaskList, error)) error {
+	list, err := load(store)
+	if err != nil {
+		return err
+	}
+	_, err = modify(list)
+	return err
+}
+
+// load reads the tasks of store along with the archived tasks kept in the
+// archive store, if any.
+func load(store storage.Store) (todo.TaskList, error) {
+	list, err := store.Load()
+	if err != nil || archiveStore == nil {
+		return list, err
+	}
+	archive, err := archiveStore.Load()
+	if err != nil {
+		return list, err
+	}
+	list.Archived = append(list.Archived, archive.Archived...)
+	list.NextID = max(list.NextID, archive.NextID)
+	return list, nil
+}
+
+// save writes list back, moving the archived tasks to the archive store if
+// one is set. The archive is written first, so that an interruption leaves
+// tasks in both stores rather than in neither.
+func save(store storage.Store, list todo.TaskList) error {
+	if archiveStore == nil {
+		return store.Save(list)
+	}
+	if err := archiveStore.Save(todo.TaskList{NextID: list.NextID, Tasks: []todo.Task{}, Archived: list.Archived}); err != nil {
+		return err
+	}
+	list.Archived = nil
+	return store.Save(list)
 }
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// Annotations share a single text cell in the CSV and SQLite stores, one per
+// line: an RFC 3339 time, a space and the text, with backslashes and line
+// breaks in the text escaped as \\ and \n.
+var annotationEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func formatAnnotations(annotations []todo.Annotation) string {
+	lines := make([]string, len(annotations))
+	for i, annotation := range annotations {
+		lines[i] = annotation.Time.Format(time.RFC3339Nano) + " " + annotationEscaper.Replace(annotation.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseAnnotations(value string) ([]todo.Annotation, error) {
+	var annotations []todo.Annotation
+	for _, line := range strings.Split(todo.NormalizeNotes(value), "\n") {
+		if line == "" {
+			continue
+		}
+		stamp, text, _ := strings.Cut(line, " ")
+		parsed, err := time.Parse(time.RFC3339Nano, stamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation time %q", stamp)
+		}
+		annotations = append(annotations, todo.Annotation{Time: parsed, Text: unescapeAnnotation(text)})
+	}
+	return annotations, nil
+}
+
+func unescapeAnnotation(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped && r == 'n':
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
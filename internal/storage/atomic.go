@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+// writeFileAtomic replaces path with whatever write produces: the data goes
+// to a temporary file in the same directory, is fsynced and then renamed over
+// the target, so readers and crashes only ever observe the old or the new file.
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		logging.Logger.Error("Error creating a temporary file", "error", err.Error(), "dir", dir)
+		return fmt.Errorf("failed to create a temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	mode := os.FileMode(0644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", tmp.Name(), err)
+	}
+
+	buffered := bufio.NewWriter(tmp)
+	if err := write(buffered); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		logging.Logger.Error("Error syncing a temporary file", "error", err.Error(), "file", tmp.Name())
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		logging.Logger.Error("Error replacing the storage file", "error", err.Error(), "file", path)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir persists the rename itself. Not every platform can fsync a
+// directory, so failures are only logged.
+func syncDir(dir string) {
+	handle, err := os.Open(dir)
+	if err != nil {
+		logging.Logger.Debug("Could not open the storage directory for syncing", "error", err.Error(), "dir", dir)
+		return
+	}
+	defer handle.Close()
+	if err := handle.Sync(); err != nil {
+		logging.Logger.Debug("Could not sync the storage directory", "error", err.Error(), "dir", dir)
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+	if err := os.WriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("failed write keeps the original", func(t *testing.T) {
+		err := writeFileAtomic(path, func(w io.Writer) error {
+			w.Write([]byte("partial"))
+			return errors.New("interrupted")
+		})
+		if err == nil {
+			t.Fatal("Test failed: Expected an error but didn't get one")
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != "original" {
+			t.Errorf("Test failed: file content changed to %q", content)
+		}
+	})
+
+	t.Run("successful write replaces the file", func(t *testing.T) {
+		err := writeFileAtomic(path, func(w io.Writer) error {
+			_, err := w.Write([]byte("replaced"))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		content, _ := os.ReadFile(path)
+		if string(content) != "replaced" {
+			t.Errorf("Test failed: got %q", content)
+		}
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Test failed: permissions changed to %v", info.Mode().Perm())
+		}
+	})
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Test failed: temporary files left behind: %v", entries)
+	}
+}
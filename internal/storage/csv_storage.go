@@ -4,83 +4,364 @@ import (
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vladiakimenko/go_project_planner/internal/logging"
 	"github.com/vladiakimenko/go_project_planner/internal/todo"
 )
 
-func LoadCSV(path string) ([]todo.Task, error) {
+const csvNextIDMarker string = "#NextID"
+
+var (
+	csvHeaders         = []string{"ID", "Description", "Status", "Due", "Scheduled", "Priority", "Project", "Tags", "Parent", "DependsOn", "Recurrence", "Created", "Updated", "Completed", "Annotations", "Notes", "Intervals", "Estimate", "Archived", "UUID"}
+	csvRequiredHeaders = []string{"ID", "Description"}
+)
+
+// csvLegacyDoneHeader is the boolean column files written before workflow
+// statuses used instead of Status.
+const csvLegacyDoneHeader string = "Done"
+
+// Tags share a single cell, separated by spaces; tags themselves cannot
+// contain whitespace.
+const csvTagSeparator string = " "
+
+func LoadCSV(path string) (todo.TaskList, error) {
 	tasks := []todo.Task{}
 	if _, err := os.Stat(path); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			logging.Logger.Debug(fmt.Sprintf("The csv storage file is missing. Creating %s", path))
-			SaveCSV(path, tasks)
+			SaveCSV(path, todo.TaskList{Tasks: tasks})
 		} else {
 			logging.Logger.Error("File stats request failed unexpectively", "error", err.Error(), "file", path)
-			return tasks, fmt.Errorf("failed to access csv storage: %w", err)
+			return todo.TaskList{Tasks: tasks}, fmt.Errorf("failed to access csv storage: %w", err)
 		}
 	}
 	file, err := os.Open(path)
 	if err != nil {
 		logging.Logger.Error("Error reading the csv storage file", "error", err.Error(), "file", path)
-		return tasks, fmt.Errorf("failed to read csv storage: %w", err)
+		return todo.TaskList{Tasks: tasks}, fmt.Errorf("failed to read csv storage: %w", err)
 	}
 	defer file.Close()
 
-	data, err := csv.NewReader(file).ReadAll()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // the NextID record is shorter than the task rows
+	data, err := reader.ReadAll()
+	var archived []todo.Task // archived tasks are the rows with an Archived time
 	if err != nil {
 		logging.Logger.Error("Error parsing the csv storage file", "error", err.Error(), "file", path)
-		return tasks, fmt.Errorf("failed to parse csv: %w", err)
+		return todo.TaskList{Tasks: tasks}, fmt.Errorf("failed to parse csv: %w", err)
+	}
+	nextID := 0
+	if len(data) > 0 && data[0][0] == csvNextIDMarker {
+		if len(data[0]) < 2 {
+			return todo.TaskList{Tasks: []todo.Task{}}, errors.New("the NextID record has no value")
+		}
+		nextID, err = strconv.Atoi(data[0][1])
+		if err != nil {
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid NextID format: %v", data[0][1])
+		}
+		data = data[1:]
+	}
+	if len(data) == 0 {
+		return todo.TaskList{Tasks: []todo.Task{}}, errors.New("the csv storage has no header row")
+	}
+	columns := map[string]int{}
+	for i, header := range data[0] {
+		columns[header] = i
+	}
+	for _, header := range csvRequiredHeaders {
+		if _, ok := columns[header]; !ok {
+			logging.Logger.Error("Required csv column is missing", "column", header, "headers", data[0])
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("the csv storage has no %s column", header)
+		}
+	}
+	_, hasStatus := columns["Status"]
+	if _, hasDone := columns[csvLegacyDoneHeader]; !hasStatus && !hasDone {
+		logging.Logger.Error("Required csv column is missing", "column", "Status", "headers", data[0])
+		return todo.TaskList{Tasks: []todo.Task{}}, errors.New("the csv storage has no Status column")
 	}
 	for _, row := range data[1:] {
-		if len(row) < 3 {
+		if len(row) != len(data[0]) {
 			logging.Logger.Error("Error desierializing a row. Wrong number of values", "row", row)
-			return []todo.Task{}, errors.New("could not create a Task from a row, wrong number of values found")
+			return todo.TaskList{Tasks: []todo.Task{}}, errors.New("could not create a Task from a row, wrong number of values found")
 		}
-		convertedId, err := strconv.Atoi(row[0])
+		task, err := unmarshalCSVTask(row, columns)
 		if err != nil {
-			return []todo.Task{}, fmt.Errorf("invalid ID format: %v", row[0])
+			return todo.TaskList{Tasks: []todo.Task{}}, err
 		}
-		convertedDone, err := strconv.ParseBool(row[2])
-		if err != nil {
-			return []todo.Task{}, fmt.Errorf("invalid Done format: %v", row[2])
+		if task.ArchivedAt != nil {
+			archived = append(archived, task)
+			continue
 		}
-		tasks = append(tasks, todo.Task{
-			ID:          convertedId,
-			Description: row[1],
-			Done:        convertedDone,
-		})
+		tasks = append(tasks, task)
 	}
-	logging.Logger.Debug("Loaded tasks from csv", "amount", len(tasks))
-	return tasks, nil
+	list := todo.TaskList{NextID: nextID, Tasks: tasks, Archived: archived}.Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid csv storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid csv storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from csv", "amount", len(list.Tasks), "archived", len(list.Archived), "next_id", list.NextID)
+	return list, nil
 }
 
-func SaveCSV(path string, tasks []todo.Task) error {
+func SaveCSV(path string, list todo.TaskList) error {
+	err := writeFileAtomic(path, func(file io.Writer) error {
+		writer := csv.NewWriter(file)
 
-	file, err := os.Create(path)
+		nextIDRecord := []string{csvNextIDMarker, strconv.Itoa(list.NextID)}
+		if err := writer.Write(nextIDRecord); err != nil {
+			logging.Logger.Error("Error writing the NextID record", "error", err.Error(), "record", nextIDRecord)
+			return fmt.Errorf("failed to write NextID to csv: %w", err)
+		}
+		if err := writer.Write(csvHeaders); err != nil {
+			logging.Logger.Error("Error writing headers", "error", err.Error(), "headers", csvHeaders)
+			return fmt.Errorf("failed to write headers to csv: %w", err)
+		}
+		for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+			serializedRow := marshalCSVTask(task)
+			if err := writer.Write(serializedRow); err != nil {
+				logging.Logger.Error("Error writing a row", "error", err.Error(), "task", task)
+				return fmt.Errorf("failed to write a row to csv: %w", err)
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
 	if err != nil {
-		logging.Logger.Error("Error creating file", "error", err.Error(), "path", path)
-		return fmt.Errorf("failed to create a csv storage: %w", err)
+		logging.Logger.Error("Error saving the csv storage", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to save csv storage: %w", err)
 	}
-	defer file.Close()
+	logging.Logger.Debug("Save tasks to csv", "amount", len(list.Tasks), "next_id", list.NextID)
+	return nil
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+func marshalCSVTask(task todo.Task) []string {
+	return []string{
+		strconv.Itoa(task.ID),
+		task.Description,
+		string(task.Status),
+		formatCSVTime(task.Due),
+		formatCSVTime(task.Scheduled),
+		task.Priority.String(),
+		task.Project,
+		strings.Join(task.Tags, csvTagSeparator),
+		formatCSVID(task.Parent),
+		formatCSVIDs(task.DependsOn),
+		formatCSVRecurrence(task.Recurrence),
+		formatCSVTime(task.CreatedAt),
+		formatCSVTime(task.UpdatedAt),
+		formatCSVTime(task.CompletedAt),
+		formatAnnotations(task.Annotations),
+		task.Notes,
+		formatIntervals(task.Intervals),
+		formatCSVEstimate(task.Estimate),
+		formatCSVTime(task.ArchivedAt),
+		task.UUID,
+	}
+}
 
-	headers := []string{"ID", "Description", "Done"}
-	if err := writer.Write(headers); err != nil {
-		logging.Logger.Error("Error writing headers", "error", err.Error(), "headers", headers)
-		return fmt.Errorf("failed to write headers to csv: %w", err)
+// unmarshalCSVTask reads a row by header name, so files written before a
+// column was introduced still load with that field left empty.
+func unmarshalCSVTask(row []string, columns map[string]int) (todo.Task, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return row[i]
+		}
+		return ""
+	}
+	convertedId, err := strconv.Atoi(field("ID"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid ID format: %v", field("ID"))
+	}
+	status, err := parseCSVStatus(field("Status"), field(csvLegacyDoneHeader))
+	if err != nil {
+		return todo.Task{}, err
+	}
+	due, err := parseCSVTime(field("Due"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Due format: %v", field("Due"))
+	}
+	scheduled, err := parseCSVTime(field("Scheduled"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Scheduled format: %v", field("Scheduled"))
 	}
-	for _, task := range tasks {
-		serializedRow := []string{strconv.Itoa(task.ID), task.Description, strconv.FormatBool(task.Done)}
-		if err := writer.Write(serializedRow); err != nil {
-			logging.Logger.Error("Error writing a row", "error", err.Error(), "task", task)
-			return fmt.Errorf("failed to write a row to csv: %w", err)
+	timestamps := map[string]*time.Time{}
+	for _, column := range []string{"Created", "Updated", "Completed", "Archived"} {
+		if timestamps[column], err = parseCSVTime(field(column)); err != nil {
+			return todo.Task{}, fmt.Errorf("invalid %s format: %v", column, field(column))
 		}
 	}
-	logging.Logger.Debug("Save tasks to csv", "amount", len(tasks))
-	return nil
+	parent, err := parseCSVID(field("Parent"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Parent format: %v", field("Parent"))
+	}
+	dependsOn, err := parseCSVIDs(field("DependsOn"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid DependsOn format: %v", field("DependsOn"))
+	}
+	recurrence, err := parseCSVRecurrence(field("Recurrence"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Recurrence format: %v", field("Recurrence"))
+	}
+	annotations, err := parseAnnotations(field("Annotations"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Annotations format: %w", err)
+	}
+	intervals, err := parseIntervals(field("Intervals"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Intervals format: %w", err)
+	}
+	estimate, err := parseCSVEstimate(field("Estimate"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Estimate format: %v", field("Estimate"))
+	}
+	priority, err := todo.ParsePriority(field("Priority"))
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("invalid Priority format: %v", field("Priority"))
+	}
+	return todo.Task{
+		ID:          convertedId,
+		Description: field("Description"),
+		Status:      status,
+		Due:         due,
+		Scheduled:   scheduled,
+		Priority:    priority,
+		Estimate:    estimate,
+		Project:     field("Project"),
+		Tags:        strings.Fields(field("Tags")),
+		Parent:      parent,
+		DependsOn:   dependsOn,
+		Recurrence:  recurrence,
+		CreatedAt:   timestamps["Created"],
+		UpdatedAt:   timestamps["Updated"],
+		CompletedAt: timestamps["Completed"],
+		ArchivedAt:  timestamps["Archived"],
+		Annotations: annotations,
+		Notes:       todo.NormalizeNotes(field("Notes")),
+		Intervals:   intervals,
+		UUID:        field("UUID"),
+	}, nil
+}
+
+// parseCSVStatus prefers the Status cell and falls back on the legacy Done
+// cell: true becomes done, false the initial status of the workflow.
+func parseCSVStatus(status string, done string) (todo.Status, error) {
+	if status != "" {
+		return todo.Status(status), nil
+	}
+	if done == "" {
+		return todo.ActiveWorkflow.Initial(), nil
+	}
+	convertedDone, err := strconv.ParseBool(done)
+	if err != nil {
+		return "", fmt.Errorf("invalid Done format: %v", done)
+	}
+	if convertedDone {
+		return todo.StatusDone, nil
+	}
+	return todo.ActiveWorkflow.Initial(), nil
+}
+
+func formatCSVID(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+func parseCSVID(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// Dependencies share a cell like tags do.
+func formatCSVIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, csvTagSeparator)
+}
+
+func parseCSVIDs(value string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Fields(value) {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func formatCSVRecurrence(value *todo.Recurrence) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func parseCSVRecurrence(value string) (*todo.Recurrence, error) {
+	if value == "" {
+		return nil, nil
+	}
+	rule := &todo.Recurrence{}
+	if err := rule.UnmarshalText([]byte(value)); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func formatCSVEstimate(value *todo.Estimate) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func parseCSVEstimate(value string) (*todo.Estimate, error) {
+	if value == "" {
+		return nil, nil
+	}
+	estimate, err := todo.ParseEstimate(value)
+	if err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+func formatCSVTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339Nano)
+}
+
+func parseCSVTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func init() {
+	Register("csv", func(path string) (Store, error) {
+		return NewFileStore(path, LoadCSV, SaveCSV), nil
+	}, ".csv")
 }
@@ -0,0 +1,509 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+const (
+	icsProductID = "-//go_project_planner//todo//EN"
+	// icsUIDSuffix follows the task id in the UID of its VTODO.
+	icsUIDSuffix      = "@go-project-planner"
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405Z"
+	icsLocalLayout    = "20060102T150405"
+	// icsLineLimit is the longest content line in octets, longer ones are folded
+	icsLineLimit = 75
+)
+
+// Fields RFC 5545 has no property for are kept in X- properties.
+const (
+	icsNextID         = "X-TODO-NEXT-ID"
+	icsStatus         = "X-TODO-STATUS"
+	icsProject        = "X-TODO-PROJECT"
+	icsEstimate       = "X-TODO-ESTIMATE"
+	icsArchived       = "X-TODO-ARCHIVED"
+	icsCompletionRule = "X-TODO-RRULE"
+)
+
+var icsStatuses = map[todo.Status]string{
+	todo.StatusTodo:       "NEEDS-ACTION",
+	todo.StatusInProgress: "IN-PROCESS",
+	todo.StatusDone:       "COMPLETED",
+	todo.StatusCancelled:  "CANCELLED",
+}
+
+var (
+	icsEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	icsUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// LoadICS reads the VTODO components of an iCalendar file; other components
+// are skipped. VTODOs with a UID not written by SaveICS get the ids following
+// the largest one in the file. Unlike a task store, a missing file is an error.
+func LoadICS(path string) (todo.TaskList, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the ics storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read ics storage: %w", err)
+	}
+	nextID, components, err := parseICS(fileBytes)
+	if err != nil {
+		logging.Logger.Error("Error parsing the ics storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to parse ics: %w", err)
+	}
+
+	// every UID gets its id before any VTODO is converted, as RELATED-TO may
+	// refer to a VTODO further down the file
+	ids, uids := map[string]int{}, make([]string, len(components))
+	for i, component := range components {
+		if uids[i] = icsValue(component, "UID"); uids[i] == "" {
+			uids[i] = fmt.Sprintf("#%d", i)
+		}
+		if id, ok := parseICSUID(uids[i]); ok {
+			ids[uids[i]] = id
+			nextID = max(nextID, id+1)
+		}
+	}
+	for _, uid := range uids {
+		if _, ok := ids[uid]; !ok {
+			ids[uid] = nextID
+			nextID++
+		}
+	}
+	list := todo.TaskList{Tasks: []todo.Task{}}
+	for i, component := range components {
+		uid := uids[i]
+		task, err := unmarshalICSTask(component, ids[uid], ids)
+		if err != nil {
+			logging.Logger.Error("Error converting a VTODO to a task", "error", err.Error(), "uid", uid, "file", path)
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid VTODO %s: %w", uid, err)
+		}
+		if task.ArchivedAt != nil {
+			list.Archived = append(list.Archived, task)
+		} else {
+			list.Tasks = append(list.Tasks, task)
+		}
+	}
+	list.NextID = nextID
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid ics storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid ics storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from ics", "amount", len(list.Tasks), "archived", len(list.Archived), "next_id", list.NextID)
+	return list, nil
+}
+
+// SaveICS writes one VTODO per task. Tracked time is not saved.
+func SaveICS(path string, list todo.TaskList) error {
+	var b bytes.Buffer
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:"+icsProductID)
+	writeICSLine(&b, icsNextID+":"+strconv.Itoa(list.NextID))
+	for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+		for _, line := range marshalICSTask(task) {
+			writeICSLine(&b, line)
+		}
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(b.Bytes())
+		return err
+	}); err != nil {
+		logging.Logger.Error("Error saving the ics storage", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to save ics storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to ics", "amount", len(list.Tasks), "archived", len(list.Archived), "next_id", list.NextID)
+	return nil
+}
+
+func marshalICSTask(task todo.Task) []string {
+	lines := []string{"BEGIN:VTODO", "UID:" + icsUID(task.ID)}
+	stamp := todo.Now()
+	if task.UpdatedAt != nil {
+		stamp = *task.UpdatedAt
+	}
+	lines = append(lines, "DTSTAMP:"+formatICSDateTime(stamp))
+	if task.CreatedAt != nil {
+		lines = append(lines, "CREATED:"+formatICSDateTime(*task.CreatedAt))
+	}
+	if task.UpdatedAt != nil {
+		lines = append(lines, "LAST-MODIFIED:"+formatICSDateTime(*task.UpdatedAt))
+	}
+	lines = append(lines, "SUMMARY:"+icsEscaper.Replace(task.Description))
+	if task.Notes != "" {
+		lines = append(lines, "DESCRIPTION:"+icsEscaper.Replace(task.Notes))
+	}
+	if status, ok := icsStatuses[task.Status]; ok {
+		lines = append(lines, "STATUS:"+status)
+	} else {
+		lines = append(lines, "STATUS:"+icsStatuses[todo.StatusTodo], icsStatus+":"+icsEscaper.Replace(string(task.Status)))
+	}
+	if task.Priority != todo.PriorityNone {
+		// iCalendar counts from 1 (highest) to 9 (lowest)
+		lines = append(lines, "PRIORITY:"+strconv.Itoa(int(todo.PriorityHigh+todo.PriorityLow-task.Priority)))
+	}
+	if task.Scheduled != nil {
+		lines = append(lines, "DTSTART;VALUE=DATE:"+task.Scheduled.Format(icsDateLayout))
+	}
+	if task.Due != nil {
+		lines = append(lines, "DUE;VALUE=DATE:"+task.Due.Format(icsDateLayout))
+	}
+	if task.CompletedAt != nil {
+		lines = append(lines, "COMPLETED:"+formatICSDateTime(*task.CompletedAt))
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			categories[i] = icsEscaper.Replace(tag)
+		}
+		lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	if task.Project != "" {
+		lines = append(lines, icsProject+":"+icsEscaper.Replace(task.Project))
+	}
+	if task.Parent != nil {
+		lines = append(lines, "RELATED-TO;RELTYPE=PARENT:"+icsUID(*task.Parent))
+	}
+	for _, id := range task.DependsOn {
+		lines = append(lines, "RELATED-TO;RELTYPE=DEPENDS-ON:"+icsUID(id))
+	}
+	if task.Recurrence != nil {
+		// calendars do not know rules counting from the completion
+		name := "RRULE"
+		if task.Recurrence.FromCompletion {
+			name = icsCompletionRule
+		}
+		lines = append(lines, name+":"+task.Recurrence.String())
+	}
+	if task.Estimate != nil {
+		lines = append(lines, icsEstimate+":"+task.Estimate.String())
+	}
+	for _, annotation := range task.Annotations {
+		lines = append(lines, "COMMENT:"+icsEscaper.Replace(annotation.Time.Format(time.RFC3339Nano)+" "+annotation.Text))
+	}
+	if task.ArchivedAt != nil {
+		lines = append(lines, icsArchived+":"+formatICSDateTime(*task.ArchivedAt))
+	}
+	return append(lines, "END:VTODO")
+}
+
+func unmarshalICSTask(component []icsProperty, id int, ids map[string]int) (todo.Task, error) {
+	task := todo.Task{ID: id, Status: todo.ActiveWorkflow.Initial()}
+	var stamp *time.Time
+	for _, property := range component {
+		var err error
+		switch property.Name {
+		case "SUMMARY":
+			task.Description = strings.TrimSpace(icsUnescaper.Replace(property.Value))
+		case "DESCRIPTION":
+			task.Notes = todo.NormalizeNotes(icsUnescaper.Replace(property.Value))
+		case "STATUS":
+			task.Status = parseICSStatus(property.Value)
+		case icsStatus:
+			task.Status = todo.Status(icsUnescaper.Replace(property.Value))
+		case "PRIORITY":
+			var priority int
+			if priority, err = strconv.Atoi(property.Value); err == nil && (priority < 0 || priority > 9) {
+				err = fmt.Errorf("out of range")
+			}
+			if priority > 0 {
+				task.Priority = todo.PriorityHigh + todo.PriorityLow - todo.Priority(priority)
+			}
+		case "DTSTART":
+			task.Scheduled, err = parseICSDate(property)
+		case "DUE":
+			task.Due, err = parseICSDate(property)
+		case "DTSTAMP":
+			stamp, err = parseICSTime(property)
+		case "CREATED":
+			task.CreatedAt, err = parseICSTime(property)
+		case "LAST-MODIFIED":
+			task.UpdatedAt, err = parseICSTime(property)
+		case "COMPLETED":
+			task.CompletedAt, err = parseICSTime(property)
+		case icsArchived:
+			task.ArchivedAt, err = parseICSTime(property)
+		case "CATEGORIES":
+			for _, category := range splitICSList(property.Value) {
+				// categories may hold spaces, tags cannot
+				tag := strings.Join(strings.Fields(icsUnescaper.Replace(category)), "-")
+				if tag != "" {
+					task.Tags = append(task.Tags, tag)
+				}
+			}
+		case icsProject:
+			task.Project = icsUnescaper.Replace(property.Value)
+		case "RELATED-TO":
+			related, ok := ids[property.Value]
+			switch {
+			case !ok:
+				// relations to tasks missing from the file are dropped
+				logging.Logger.Warn("Dropping a relation to a task missing from the file", "related_to", property.Value)
+			case strings.EqualFold(property.Params["RELTYPE"], "DEPENDS-ON"):
+				task.DependsOn = append(task.DependsOn, related)
+			case property.Params["RELTYPE"] == "" || strings.EqualFold(property.Params["RELTYPE"], "PARENT"):
+				task.Parent = &related
+			}
+		case "RRULE", icsCompletionRule:
+			var rule todo.Recurrence
+			if rule, err = todo.ParseRecurrence(property.Value); err != nil {
+				// rules with parts such as COUNT or UNTIL cannot be followed
+				logging.Logger.Warn("Dropping an unsupported recurrence rule", "rule", property.Value, "error", err.Error())
+				err = nil
+				continue
+			}
+			rule.FromCompletion = rule.FromCompletion || property.Name == icsCompletionRule
+			task.Recurrence = &rule
+		case icsEstimate:
+			task.Estimate, err = parseCSVEstimate(property.Value)
+		case "COMMENT":
+			task.Annotations = append(task.Annotations, parseICSComment(icsUnescaper.Replace(property.Value)))
+		}
+		if err != nil {
+			return task, fmt.Errorf("invalid %s %q: %w", property.Name, property.Value, err)
+		}
+	}
+	if task.Description == "" {
+		return task, errors.New("the VTODO has no SUMMARY")
+	}
+	// comments from other applications carry no time of their own
+	for i := range task.Annotations {
+		if task.Annotations[i].Time.IsZero() && stamp != nil {
+			task.Annotations[i].Time = *stamp
+		}
+	}
+	if task.Status != todo.StatusDone {
+		task.CompletedAt = nil
+	}
+	tags, err := todo.NormalizeTags(task.Tags)
+	if err != nil {
+		return task, err
+	}
+	task.Tags = nil
+	if len(tags) > 0 {
+		task.Tags = tags
+	}
+	return task, nil
+}
+
+// parseICSStatus maps STATUS to the active workflow: NEEDS-ACTION and unknown
+// values are its initial status, IN-PROCESS is in-progress only when the
+// workflow has it.
+func parseICSStatus(value string) todo.Status {
+	for status, name := range icsStatuses {
+		if strings.EqualFold(value, name) && status != todo.StatusTodo && slices.Contains(todo.ActiveWorkflow.Statuses, status) {
+			return status
+		}
+	}
+	return todo.ActiveWorkflow.Initial()
+}
+
+// parseICS unfolds the content lines and returns the next id recorded by
+// SaveICS along with the properties of every VTODO, leaving out those of
+// nested components such as VALARM.
+func parseICS(data []byte) (int, [][]icsProperty, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, err
+	}
+	nextID := 0
+	components := [][]icsProperty{}
+	var current []icsProperty
+	depth := 0 // of components nested in the current VTODO
+	calendar := false
+	for _, line := range lines {
+		property, err := parseICSProperty(line)
+		if err != nil {
+			return 0, nil, err
+		}
+		switch {
+		case property.Name == "BEGIN" && strings.EqualFold(property.Value, "VCALENDAR"):
+			calendar = true
+		case property.Name == "BEGIN" && current == nil && strings.EqualFold(property.Value, "VTODO"):
+			current = []icsProperty{}
+		case property.Name == "BEGIN" && current != nil:
+			depth++
+		case property.Name == "END" && current != nil && depth > 0:
+			depth--
+		case property.Name == "END" && current != nil:
+			components = append(components, current)
+			current = nil
+		case current != nil && depth == 0:
+			current = append(current, property)
+		case property.Name == icsNextID:
+			if nextID, err = strconv.Atoi(property.Value); err != nil {
+				return 0, nil, fmt.Errorf("invalid %s: %v", icsNextID, property.Value)
+			}
+		}
+	}
+	if !calendar && len(lines) > 0 {
+		return 0, nil, errors.New("no VCALENDAR found")
+	}
+	if current != nil {
+		return 0, nil, errors.New("unterminated VTODO")
+	}
+	return nextID, components, nil
+}
+
+// parseICSProperty splits "NAME;PARAM=value:value"; parameter values may be
+// quoted and contain colons.
+func parseICSProperty(line string) (icsProperty, error) {
+	quoted := false
+	colon := strings.IndexFunc(line, func(r rune) bool {
+		if r == '"' {
+			quoted = !quoted
+		}
+		return r == ':' && !quoted
+	})
+	if colon < 0 {
+		return icsProperty{}, fmt.Errorf("invalid content line %q", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	property := icsProperty{Name: strings.ToUpper(parts[0]), Params: map[string]string{}, Value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		property.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return property, nil
+}
+
+// writeICSLine ends a content line with CRLF, folding it after icsLineLimit
+// octets without splitting a character.
+func writeICSLine(b *bytes.Buffer, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icsLineLimit - 1 // the leading space counts
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func icsValue(component []icsProperty, name string) string {
+	for _, property := range component {
+		if property.Name == name {
+			return property.Value
+		}
+	}
+	return ""
+}
+
+func icsUID(id int) string {
+	return strconv.Itoa(id) + icsUIDSuffix
+}
+
+func parseICSUID(uid string) (int, bool) {
+	number, ok := strings.CutSuffix(uid, icsUIDSuffix)
+	if !ok || number == "" || strings.ContainsFunc(number, func(r rune) bool { return !unicode.IsDigit(r) }) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(number)
+	return id, err == nil
+}
+
+func formatICSDateTime(value time.Time) string {
+	return value.UTC().Format(icsDateTimeLayout)
+}
+
+// parseICSTime reads a UTC, TZID-qualified or floating date-time, or a date.
+func parseICSTime(property icsProperty) (*time.Time, error) {
+	location := time.Local
+	if tzid := property.Params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	var parsed time.Time
+	var err error
+	switch {
+	case strings.HasSuffix(property.Value, "Z"):
+		parsed, err = time.Parse(icsDateTimeLayout, property.Value)
+	case len(property.Value) == len(icsDateLayout):
+		parsed, err = time.ParseInLocation(icsDateLayout, property.Value, time.Local)
+	default:
+		parsed, err = time.ParseInLocation(icsLocalLayout, property.Value, location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parseICSDate reads a date or date-time as the start of its local day, the
+// way due and scheduled dates are kept.
+func parseICSDate(property icsProperty) (*time.Time, error) {
+	parsed, err := parseICSTime(property)
+	if err != nil {
+		return nil, err
+	}
+	date := todo.StartOfDay(parsed.Local())
+	return &date, nil
+}
+
+func parseICSComment(value string) todo.Annotation {
+	stamp, text, _ := strings.Cut(value, " ")
+	if parsed, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+		return todo.Annotation{Time: parsed, Text: text}
+	}
+	return todo.Annotation{Text: value}
+}
+
+// splitICSList splits a value at the commas that are not escaped.
+func splitICSList(value string) []string {
+	items := []string{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, value[start:])
+}
+
+func init() {
+	RegisterExchange("ics", func(path string) (Store, error) {
+		return NewFileStore(path, LoadICS, SaveICS), nil
+	}, ".ics")
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestLoadICS(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "foreign calendar",
+			content: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Example//EN\r\n" +
+				"BEGIN:VEVENT\r\nUID:meeting\r\nSUMMARY:Meeting\r\nEND:VEVENT\r\n" +
+				"BEGIN:VTODO\r\nUID:abc-1\r\nDTSTAMP:20261001T090000Z\r\nSUMMARY:Call vendor\\, then\r\n  write back\r\n" +
+				"STATUS:COMPLETED\r\nCOMPLETED:20261002T100000Z\r\nPRIORITY:1\r\nDUE;TZID=Europe/Berlin:20261020T230000\r\n" +
+				"CATEGORIES:phone,long term\r\nCOMMENT:asked for a quote\r\n" +
+				"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nUID:abc-2\r\nSUMMARY:Follow up\r\nRELATED-TO:abc-1\r\nRRULE:FREQ=WEEKLY;COUNT=3\r\nEND:VTODO\r\n" +
+				"END:VCALENDAR\r\n",
+			expected: []todo.Task{
+				{
+					ID: 0, Description: "Call vendor, then write back", Status: todo.StatusDone, Priority: todo.PriorityHigh,
+					Tags: []string{"phone", "long-term"}, Due: testDate(2026, 10, 20), CompletedAt: testTime("2026-10-02T10:00:00Z"),
+					Annotations: []todo.Annotation{{Time: *testTime("2026-10-01T09:00:00Z"), Text: "asked for a quote"}},
+				},
+				{ID: 1, Description: "Follow up", Status: todo.StatusTodo, Parent: testID(0)},
+			},
+		},
+		{name: "empty file", content: "", expected: []todo.Task{}},
+		{name: "no calendar", content: "SUMMARY:Call vendor\r\n", errorExpected: true},
+		{name: "no summary", content: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:a\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", errorExpected: true},
+		{name: "unterminated", content: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:a\r\n", errorExpected: true},
+		{
+			name:     "unknown relation",
+			content:  "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:a\r\nRELATED-TO:b\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			expected: []todo.Task{{ID: 0, Description: "a", Status: todo.StatusTodo}},
+		},
+		{
+			name: "child before its parent",
+			content: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:child-1\r\nSUMMARY:Child\r\nRELATED-TO:parent-1\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nUID:parent-1\r\nSUMMARY:Parent\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			expected: []todo.Task{
+				{ID: 0, Description: "Child", Status: todo.StatusTodo, Parent: testID(1)},
+				{ID: 1, Description: "Parent", Status: todo.StatusTodo},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todo.ics")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadICS(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadICSWorkflow(t *testing.T) {
+	workflow, err := todo.ParseWorkflow("backlog > doing, done, cancelled; doing > done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.ActiveWorkflow = workflow
+	defer func() { todo.ActiveWorkflow = todo.DefaultWorkflow }()
+	path := filepath.Join(t.TempDir(), "todo.ics")
+	content := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:a\r\nSUMMARY:Open\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:b\r\nSUMMARY:Started\r\nSTATUS:IN-PROCESS\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:c\r\nSUMMARY:Dropped\r\nSTATUS:CANCELLED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadICS(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	statuses := []todo.Status{}
+	for _, task := range list.Tasks {
+		statuses = append(statuses, task.Status)
+	}
+	if expected := []todo.Status{"backlog", "backlog", todo.StatusCancelled}; !slices.Equal(statuses, expected) {
+		t.Errorf("Test failed: got statuses %v, expected %v", statuses, expected)
+	}
+}
+
+func TestSaveICS(t *testing.T) {
+	parent := 0
+	list := todo.NewTaskList([]todo.Task{
+		{
+			ID: 0, Description: "Release; v2, " + strings.Repeat("ünïcode", 20), Status: todo.StatusInProgress,
+			Priority: 8, Project: "work", Tags: []string{"urgent"}, Due: testDate(2026, 10, 20),
+			CreatedAt: testTime("2026-10-01T09:00:00Z"), UpdatedAt: testTime("2026-10-02T09:00:00Z"),
+			Notes: "first line\nsecond line", Estimate: &todo.Estimate{Amount: 5, Unit: todo.Points},
+		},
+		{
+			ID: 1, Description: "Changelog", Status: todo.StatusDone, Priority: todo.PriorityLow, Parent: &parent,
+			CompletedAt: testTime("2026-10-03T12:30:00Z"), UpdatedAt: testTime("2026-10-03T12:30:00Z"),
+			Annotations: []todo.Annotation{{Time: *testTime("2026-10-03T12:00:00Z"), Text: "drafted, reviewed"}},
+		},
+		{
+			ID: 4, Description: "Standup", Status: todo.StatusTodo, DependsOn: []int{0, 1}, UpdatedAt: testTime("2026-10-01T09:00:00Z"),
+			Scheduled: testDate(2026, 10, 19), Recurrence: testRecurrence("FREQ=DAILY;INTERVAL=2;FROM=COMPLETION"),
+		},
+	}, 0)
+	path := filepath.Join(t.TempDir(), "todo.ics")
+	if err := SaveICS(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\r\n"), "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("Test failed: line of %d octets %q", len(line), line)
+		}
+	}
+	for _, expected := range []string{
+		"UID:0@go-project-planner\r\n", "STATUS:IN-PROCESS\r\n", "PRIORITY:2\r\n", "DUE;VALUE=DATE:20261020\r\n",
+		"SUMMARY:Release\\; v2\\, ", "DESCRIPTION:first line\\nsecond line\r\n", "RELATED-TO;RELTYPE=PARENT:0@go-project-planner\r\n",
+		"RELATED-TO;RELTYPE=DEPENDS-ON:1@go-project-planner\r\n", "X-TODO-RRULE:FREQ=DAILY;INTERVAL=2;FROM=COMPLETION\r\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Test failed: %q missing from\n%s", expected, content)
+		}
+	}
+	loaded, err := LoadICS(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if !slices.EqualFunc(loaded.Tasks, list.Tasks, sameTask) || loaded.NextID != list.NextID {
+		t.Errorf("Test failed: got %+v, expected %+v", loaded, list)
+	}
+	for i := range list.Tasks {
+		if loaded.Tasks[i].Description != list.Tasks[i].Description || loaded.Tasks[i].Notes != list.Tasks[i].Notes ||
+			!slices.Equal(loaded.Tasks[i].Annotations, list.Tasks[i].Annotations) {
+			t.Errorf("Test failed: got %+v, expected %+v", loaded.Tasks[i], list.Tasks[i])
+		}
+	}
+}
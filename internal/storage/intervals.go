@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// Intervals share a single text cell in the CSV and SQLite stores, separated
+// by spaces: the RFC 3339 start and end joined by a slash, the end left empty
+// while the timer runs.
+const intervalSeparator string = "/"
+
+func formatIntervals(intervals []todo.Interval) string {
+	parts := make([]string, len(intervals))
+	for i, interval := range intervals {
+		parts[i] = interval.Start.Format(time.RFC3339Nano) + intervalSeparator
+		if interval.End != nil {
+			parts[i] += interval.End.Format(time.RFC3339Nano)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseIntervals(value string) ([]todo.Interval, error) {
+	var intervals []todo.Interval
+	for _, part := range strings.Fields(value) {
+		start, end, ok := strings.Cut(part, intervalSeparator)
+		if !ok {
+			return nil, fmt.Errorf("invalid interval %q, expected start/end", part)
+		}
+		interval := todo.Interval{}
+		var err error
+		if interval.Start, err = time.Parse(time.RFC3339Nano, start); err != nil {
+			return nil, fmt.Errorf("invalid interval start %q", start)
+		}
+		if end != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, end)
+			if err != nil {
+				return nil, fmt.Errorf("invalid interval end %q", end)
+			}
+			interval.End = &parsed
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals, nil
+}
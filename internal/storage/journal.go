@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// JournalPath places the journal of a store next to it.
+func JournalPath(uri string) string {
+	return Path(uri) + ".journal"
+}
+
+// LoadJournal reads the operation journal at path; a missing file is an
+// empty journal.
+func LoadJournal(path string) (todo.Journal, error) {
+	journal := todo.Journal{Operations: []todo.Operation{}}
+	fileBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		logging.Logger.Error("Error reading the journal file", "error", err.Error(), "file", path)
+		return journal, fmt.Errorf("failed to read the journal: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(fileBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&journal); err != nil {
+		logging.Logger.Error("Error unmarshalling the journal file", "error", err.Error(), "file", path)
+		return todo.Journal{Operations: []todo.Operation{}}, fmt.Errorf("failed to parse the journal: %w", err)
+	}
+	logging.Logger.Debug("Loaded the journal", "operations", len(journal.Operations))
+	return journal, nil
+}
+
+func SaveJournal(path string, journal todo.Journal) error {
+	resultBytes, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to dump the journal: %w", err)
+	}
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(resultBytes)
+		return err
+	}); err != nil {
+		logging.Logger.Error("Failed writing the journal", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to write the journal: %w", err)
+	}
+	logging.Logger.Debug("Saved the journal", "operations", len(journal.Operations))
+	return nil
+}
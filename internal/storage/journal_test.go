@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestJournalFile(t *testing.T) {
+	if got := JournalPath("csv:///tmp/tasks.data"); got != "/tmp/tasks.data.journal" {
+		t.Errorf("Test failed: journal path %s", got)
+	}
+	path := filepath.Join(t.TempDir(), "tasks.json.journal")
+	journal, err := LoadJournal(path)
+	if err != nil || len(journal.Operations) != 0 {
+		t.Fatalf("Test failed: a missing journal loaded as %v (%v)", journal, err)
+	}
+	task := todo.Task{ID: 0, Description: "Task A", Status: todo.StatusTodo, Estimate: &todo.Estimate{Amount: 2, Unit: todo.Hours}}
+	journal = journal.Record(todo.Operation{
+		Time:    time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+		Command: "add -desc 'Task A'",
+		Changes: []todo.Change{{After: &task}},
+	})
+	if err := SaveJournal(path, journal); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	loaded, err := LoadJournal(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if len(loaded.Operations) != 1 || loaded.Operations[0].Command != journal.Operations[0].Command ||
+		loaded.Operations[0].Changes[0].Before != nil || !sameTask(*loaded.Operations[0].Changes[0].After, task) {
+		t.Errorf("Test failed: got %+v, expected %+v", loaded, journal)
+	}
+}
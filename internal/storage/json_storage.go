@@ -1,49 +1,78 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/vladiakimenko/go_project_planner/internal/logging"
 	"github.com/vladiakimenko/go_project_planner/internal/todo"
 )
 
-func LoadJSON(path string) ([]todo.Task, error) {
-	tasks := []todo.Task{}
+func LoadJSON(path string) (todo.TaskList, error) {
+	list := todo.TaskList{Tasks: []todo.Task{}}
 	if _, err := os.Stat(path); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			logging.Logger.Debug(fmt.Sprintf("The json storage file is missing. Creating %s", path))
-			SaveJSON(path, tasks)
+			SaveJSON(path, list)
 		} else {
 			logging.Logger.Error("File stats request failed unexpectively", "error", err.Error(), "file", path)
-			return tasks, fmt.Errorf("failed to access json storage: %w", err)
+			return list, fmt.Errorf("failed to access json storage: %w", err)
 		}
 	}
 	fileBytes, err := os.ReadFile(path)
 	if err != nil {
 		logging.Logger.Error("Error reading the json storage file", "error", err.Error(), "file", path)
-		return tasks, fmt.Errorf("failed to read json storage: %w", err)
+		return list, fmt.Errorf("failed to read json storage: %w", err)
 	}
-	if err := json.Unmarshal(fileBytes, &tasks); err != nil {
+	// files written before the id allocator was introduced hold a bare array of tasks
+	target := any(&list)
+	if bytes.HasPrefix(bytes.TrimSpace(fileBytes), []byte("[")) {
+		target = &list.Tasks
+	}
+	decoder := json.NewDecoder(bytes.NewReader(fileBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
 		logging.Logger.Error("Error unmarshalling the json storage file", "error", err.Error(), "file", path)
-		return tasks, fmt.Errorf("failed to parse json: %w", err)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to parse json: %w", err)
+	}
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid json storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid json storage: %w", err)
 	}
-	logging.Logger.Debug("Loaded tasks from json", "amount", len(tasks))
-	return tasks, nil
+	logging.Logger.Debug("Loaded tasks from json", "amount", len(list.Tasks), "next_id", list.NextID)
+	return list, nil
 }
 
-func SaveJSON(path string, tasks []todo.Task) error {
-	resultBytes, err := json.MarshalIndent(tasks, "", "  ")
+func SaveJSON(path string, list todo.TaskList) error {
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	resultBytes, err := json.MarshalIndent(list, "", "  ")
 	if err != nil {
-		logging.Logger.Error("Error marshalling tasks to json", "error", err.Error(), "tasks", tasks)
+		logging.Logger.Error("Error marshalling tasks to json", "error", err.Error(), "tasks", list.Tasks)
 		return fmt.Errorf("failed to dump json: %w", err)
 	}
-	if err := os.WriteFile(path, resultBytes, 0644); err != nil {
-		logging.Logger.Error("Failed writing to file", "error", err.Error(), "tasks", tasks, "path", path)
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(resultBytes)
+		return err
+	}); err != nil {
+		logging.Logger.Error("Failed writing to file", "error", err.Error(), "tasks", list.Tasks, "path", path)
 		return fmt.Errorf("failed to write to json storage: %w", err)
 	}
-	logging.Logger.Debug("Save tasks to json", "amount", len(tasks))
+	logging.Logger.Debug("Save tasks to json", "amount", len(list.Tasks), "next_id", list.NextID)
 	return nil
 }
+
+func init() {
+	Register("json", func(path string) (Store, error) {
+		return NewFileStore(path, LoadJSON, SaveJSON), nil
+	}, ".json")
+}
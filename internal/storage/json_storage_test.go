@@ -36,12 +36,24 @@ func TestLoadJSON(t *testing.T) {
 				return path
 			},
 			expected: []todo.Task{
-				{ID: 0, Description: "Task A", Done: false},
-				{ID: 1, Description: "Task B", Done: true},
-				{ID: 2, Description: "Task C", Done: false},
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+				{ID: 1, Description: "Task B", Status: todo.StatusDone},
+				{ID: 2, Description: "Task C", Status: todo.StatusTodo},
 			},
 			errorExpected: false,
 		},
+		{
+			name: "duplicate ids",
+			setup: func() string {
+				path := filepath.Join(t.TempDir(), "duplicate.json")
+				if err := os.WriteFile(path, []byte(`{"NextID":2,"Tasks":[{"ID":1,"Description":"Task A","Done":false},{"ID":1,"Description":"Task B","Done":true}]}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			expected:      []todo.Task{},
+			errorExpected: true,
+		},
 		{
 			name: "file does not exist - creates new",
 			setup: func() string {
@@ -74,11 +86,12 @@ func TestLoadJSON(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			path := tt.setup()
-			result, err := LoadJSON(path)
+			loaded, err := LoadJSON(path)
 			if (err != nil) != tt.errorExpected {
 				t.Errorf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
 				return
 			}
+			result := loaded.Tasks
 
 			if len(result) != len(tt.expected) {
 				t.Errorf("Test failed: got %d tasks, expected %d", len(result), len(tt.expected))
@@ -86,9 +99,7 @@ func TestLoadJSON(t *testing.T) {
 			}
 
 			for i := range result {
-				if result[i].ID != tt.expected[i].ID ||
-					result[i].Description != tt.expected[i].Description ||
-					result[i].Done != tt.expected[i].Done {
+				if !sameTask(result[i], tt.expected[i]) {
 					t.Errorf("Test failed: task %d = %v, expected %v", i, result[i], tt.expected[i])
 				}
 			}
@@ -104,39 +115,164 @@ func TestSaveJSON(t *testing.T) {
 		{
 			name: "successful save",
 			tasks: []todo.Task{
-				{ID: 0, Description: "Task A", Done: false},
-				{ID: 1, Description: "Task B", Done: true},
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+				{ID: 1, Description: "Task B", Status: todo.StatusDone},
 			},
 		},
 		{
 			name:  "empty tasks",
 			tasks: []todo.Task{},
 		},
+		{
+			name: "tasks with dates",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo, Due: testDate(2026, 10, 20)},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Scheduled: testDate(2026, 10, 18), Due: testDate(2026, 11, 1)},
+			},
+		},
+		{
+			name: "tasks with priorities and estimates",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo, Priority: todo.PriorityHigh},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Priority: todo.PriorityNone},
+				{ID: 2, Description: "Task C", Status: todo.StatusTodo, Priority: 3},
+				{ID: 3, Description: "Task D", Status: todo.StatusTodo, Estimate: &todo.Estimate{Amount: 1.5, Unit: todo.Hours}},
+				{ID: 4, Description: "Task E", Status: todo.StatusTodo, Estimate: &todo.Estimate{Amount: 0.5, Unit: todo.Points}},
+			},
+		},
+		{
+			name: "tasks with tags and projects",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo, Project: "work.backend", Tags: []string{"urgent", "db"}},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Project: "home"},
+				{ID: 2, Description: "Task C", Status: todo.StatusTodo, Tags: []string{"errand"}},
+			},
+		},
+		{
+			name: "tasks with timestamps",
+			tasks: []todo.Task{
+				{
+					ID: 0, Description: "Task A", Status: todo.StatusDone,
+					CreatedAt:   testTime("2026-10-01T09:30:00.123456789+02:00"),
+					UpdatedAt:   testTime("2026-10-02T10:00:00Z"),
+					CompletedAt: testTime("2026-10-02T10:00:00Z"),
+				},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, CreatedAt: testTime("2026-10-03T08:00:00Z"), UpdatedAt: testTime("2026-10-03T08:00:00Z")},
+			},
+		},
+		{
+			name: "subtasks",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Parent: testID(0)},
+				{ID: 2, Description: "Task C", Status: todo.StatusTodo, Parent: testID(1)},
+			},
+		},
+		{
+			name: "dependencies",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo},
+				{ID: 2, Description: "Task C", Status: todo.StatusTodo, DependsOn: []int{0, 1}},
+			},
+		},
+		{
+			name: "recurring tasks",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo, Due: testDate(2026, 10, 19), Recurrence: testRecurrence("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH")},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Recurrence: testRecurrence("FREQ=DAILY;INTERVAL=3;FROM=COMPLETION")},
+			},
+		},
+		{
+			name: "notes and annotations",
+			tasks: []todo.Task{
+				{
+					ID: 0, Description: `Task "A", with commas`, Status: todo.StatusTodo,
+					Annotations: []todo.Annotation{
+						{Time: *testTime("2026-10-18T09:30:00Z"), Text: "waiting on vendor, again"},
+						{Time: *testTime("2026-10-19T10:00:00.5+02:00"), Text: "two\nlines with a \\ backslash and \"quotes\""},
+					},
+					Notes: "# Plan\n\n- first, step\n- \"second\" step\n",
+				},
+				{ID: 1, Description: "Task B", Status: todo.StatusTodo, Notes: "\n leading and trailing space \n"},
+			},
+		},
+		{
+			name: "workflow statuses",
+			tasks: []todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusInProgress},
+				{ID: 1, Description: "Task B", Status: todo.StatusReview},
+				{ID: 2, Description: "Task C", Status: todo.StatusCancelled},
+			},
+		},
+		{
+			name: "time intervals",
+			tasks: []todo.Task{
+				{
+					ID: 0, Description: "Task A", Status: todo.StatusInProgress,
+					Intervals: []todo.Interval{
+						{Start: *testTime("2026-10-18T09:00:00Z"), End: testTime("2026-10-18T12:30:00.5+02:00")},
+						{Start: *testTime("2026-10-19T09:00:00Z")},
+					},
+				},
+				{ID: 1, Description: "Task B", Status: todo.StatusDone, Intervals: []todo.Interval{{Start: *testTime("2026-10-18T08:00:00Z"), End: testTime("2026-10-18T08:45:00Z")}}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			path := filepath.Join(t.TempDir(), "test_save.json")
 
-			if err := SaveJSON(path, tt.tasks); err != nil {
+			if err := SaveJSON(path, todo.NewTaskList(tt.tasks, 0)); err != nil {
 				t.Errorf("Test failed: Unexpected error: %v", err)
 			}
-			loadedTasks, err := LoadJSON(path)
+			loaded, err := LoadJSON(path)
 			if err != nil {
 				t.Errorf("Test failed: couldn't load the file back: %v", err)
 			}
+			loadedTasks := loaded.Tasks
 
 			if len(loadedTasks) != len(tt.tasks) {
 				t.Errorf("Test failed: saved %d tasks, but loaded %d", len(tt.tasks), len(loadedTasks))
 			}
 
 			for i := range loadedTasks {
-				if loadedTasks[i].ID != tt.tasks[i].ID ||
-					loadedTasks[i].Description != tt.tasks[i].Description ||
-					loadedTasks[i].Done != tt.tasks[i].Done {
+				if !sameTask(loadedTasks[i], tt.tasks[i]) {
 					t.Errorf("Test failed: saved task %d = %v, but loaded %v", i, tt.tasks[i], loadedTasks[i])
 				}
 			}
 		})
 	}
 }
+
+func TestJSONNextID(t *testing.T) {
+	t.Run("high-water mark survives a round-trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "next_id.json")
+		list := todo.TaskList{NextID: 7, Tasks: []todo.Task{{ID: 2, Description: "Task A", Status: todo.StatusTodo}}}
+		if err := SaveJSON(path, list); err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		loaded, err := LoadJSON(path)
+		if err != nil {
+			t.Fatalf("Test failed: couldn't load the file back: %v", err)
+		}
+		if loaded.NextID != 7 {
+			t.Errorf("Test failed: expected NextID 7, got %d", loaded.NextID)
+		}
+	})
+
+	t.Run("files without a high-water mark continue after the largest id", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "legacy.json")
+		if err := os.WriteFile(path, []byte(`[{"ID":0,"Description":"Task A","Done":false},{"ID":4,"Description":"Task B","Done":true}]`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadJSON(path)
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		if loaded.NextID != 5 {
+			t.Errorf("Test failed: expected NextID 5, got %d", loaded.NextID)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+var ErrLocked = errors.New("the store is locked by another process")
+
+const lockRetryInterval = 50 * time.Millisecond
+
+// LockFile takes an exclusive advisory lock on path, creating it if needed,
+// and retries until timeout before giving up with ErrLocked. The returned
+// function releases the lock.
+func LockFile(path string, timeout time.Duration) (func() error, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		logging.Logger.Error("Error opening the lock file", "error", err.Error(), "file", path)
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		acquired, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			logging.Logger.Error("Error locking the lock file", "error", err.Error(), "file", path)
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if acquired {
+			logging.Logger.Debug("Acquired the store lock", "file", path)
+			return func() error {
+				defer file.Close()
+				return unlock(file)
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			logging.Logger.Warn("Timed out waiting for the store lock", "file", path, "timeout", timeout)
+			return nil, fmt.Errorf("%w (lock file %s, waited %s)", ErrLocked, path, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
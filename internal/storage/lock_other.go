@@ -0,0 +1,16 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// Without flock the lock is only honoured within this process; writes are
+// still atomic, so concurrent invocations may lose updates but never corrupt
+// the store.
+func tryLock(file *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
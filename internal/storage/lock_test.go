@@ -0,0 +1,35 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json.lock")
+	unlock, err := LockFile(path, 0)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+
+	started := time.Now()
+	if _, err := LockFile(path, 150*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("Test failed: expected ErrLocked while the lock is held, got %v", err)
+	}
+	if waited := time.Since(started); waited < 150*time.Millisecond {
+		t.Errorf("Test failed: gave up after %s, before the timeout", waited)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatalf("Test failed: Unexpected error on unlock: %v", err)
+	}
+	unlockAgain, err := LockFile(path, 0)
+	if err != nil {
+		t.Fatalf("Test failed: couldn't lock after release: %v", err)
+	}
+	unlockAgain()
+}
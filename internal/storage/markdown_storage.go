@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+const (
+	markdownIndent = "  "
+	// markdownTabWidth is the number of columns a tab indents a list item by
+	markdownTabWidth = 4
+	// cancelled tasks are checked and struck through
+	markdownStrike = "~~"
+)
+
+var (
+	markdownItemPattern    = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d+[.)])[ \t]+\[([ xX])\](?:[ \t]+(.*))?$`)
+	markdownHeadingPattern = regexp.MustCompile(`^#{1,6}[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	markdownFencePattern   = regexp.MustCompile("^[ \t]*(```|~~~)")
+)
+
+// LoadMarkdown reads the "- [ ] item" and "- [x] item" checklist items of a
+// Markdown file; everything else is prose and skipped. An item indented under
+// another is its subtask, and a heading sets the project of the items below
+// it. The items are numbered in the order they appear. Unlike a task store, a
+// missing file is an error.
+func LoadMarkdown(path string) (todo.TaskList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the markdown storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read markdown storage: %w", err)
+	}
+	defer file.Close()
+
+	type openItem struct {
+		indent int
+		id     int
+	}
+	tasks := []todo.Task{}
+	project := ""
+	items := []openItem{} // the items a deeper one may be nested in
+	fenced := false
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if markdownFencePattern.MatchString(line) {
+			fenced = !fenced
+			continue
+		}
+		if fenced || line == "" {
+			continue
+		}
+		if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil {
+			project = markdownProject(match[1])
+			items = items[:0]
+			continue
+		}
+		match := markdownItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			// unindented prose ends the list
+			if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				items = items[:0]
+			}
+			continue
+		}
+		task, err := unmarshalMarkdownTask(match[2], match[3])
+		if err != nil {
+			logging.Logger.Error("Error parsing a markdown checklist item", "error", err.Error(), "line", number, "file", path)
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid markdown line %d: %w", number, err)
+		}
+		task.ID = len(tasks)
+		task.Project = project
+		indent := markdownIndentWidth(match[1])
+		for len(items) > 0 && items[len(items)-1].indent >= indent {
+			items = items[:len(items)-1]
+		}
+		if len(items) > 0 {
+			parent := items[len(items)-1].id
+			task.Parent = &parent
+		}
+		items = append(items, openItem{indent: indent, id: task.ID})
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		logging.Logger.Error("Error reading the markdown storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read markdown storage: %w", err)
+	}
+
+	list := todo.NewTaskList(tasks, 0)
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid markdown storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid markdown storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from markdown", "amount", len(list.Tasks))
+	return list, nil
+}
+
+// SaveMarkdown writes the tasks as a checklist, subtasks indented under their
+// parents. Tasks without a project come first, then a "## project" section per
+// project; a subtask stays with its parent whatever its own project. Only the
+// description and whether the task is closed are saved, archived tasks are not.
+func SaveMarkdown(path string, list todo.TaskList) error {
+	ids := map[int]bool{}
+	for _, task := range list.Tasks {
+		ids[task.ID] = true
+	}
+	children := map[int][]todo.Task{}
+	projects := map[string][]todo.Task{}
+	for _, task := range list.Tasks {
+		// a subtask exported without its parent is listed on its own
+		if task.Parent != nil && ids[*task.Parent] {
+			children[*task.Parent] = append(children[*task.Parent], task)
+		} else {
+			projects[task.Project] = append(projects[task.Project], task)
+		}
+	}
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		var write func(task todo.Task, depth int) error
+		write = func(task todo.Task, depth int) error {
+			if _, err := fmt.Fprintln(w, strings.Repeat(markdownIndent, depth)+marshalMarkdownTask(task)); err != nil {
+				return err
+			}
+			for _, child := range children[task.ID] {
+				if err := write(child, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i, name := range names {
+			if name != "" {
+				if i > 0 {
+					if _, err := fmt.Fprintln(w); err != nil {
+						return err
+					}
+				}
+				if _, err := fmt.Fprintf(w, "## %s\n\n", name); err != nil {
+					return err
+				}
+			}
+			for _, task := range projects[name] {
+				if err := write(task, 0); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Logger.Error("Error saving the markdown storage", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to save markdown storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to markdown", "amount", len(list.Tasks))
+	return nil
+}
+
+func marshalMarkdownTask(task todo.Task) string {
+	switch {
+	case task.Status == todo.StatusCancelled:
+		return "- [x] " + markdownStrike + task.Description + markdownStrike
+	case task.Status.Closed():
+		return "- [x] " + task.Description
+	default:
+		return "- [ ] " + task.Description
+	}
+}
+
+func unmarshalMarkdownTask(mark string, text string) (todo.Task, error) {
+	task := todo.Task{Status: todo.ActiveWorkflow.Initial(), Description: strings.TrimSpace(text)}
+	if mark != " " {
+		task.Status = todo.StatusDone
+		struck := strings.TrimSuffix(strings.TrimPrefix(task.Description, markdownStrike), markdownStrike)
+		if len(struck)+2*len(markdownStrike) == len(task.Description) {
+			task.Status = todo.StatusCancelled
+			task.Description = strings.TrimSpace(struck)
+		}
+	}
+	if task.Description == "" {
+		return task, errors.New("the checklist item has no text")
+	}
+	return task, nil
+}
+
+// markdownProject turns a heading into a project name: spaces become "-" and
+// empty path segments are dropped.
+func markdownProject(heading string) string {
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Join(strings.Fields(heading), "-"), ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+func markdownIndentWidth(indent string) int {
+	width := 0
+	for _, r := range indent {
+		if r == '\t' {
+			width += markdownTabWidth
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+func init() {
+	RegisterExchange("md", func(path string) (Store, error) {
+		return NewFileStore(path, LoadMarkdown, SaveMarkdown), nil
+	}, ".md", ".markdown")
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestLoadMarkdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "pull request description",
+			content: "Fixes the release script.\n\n- [x] Bump the version\n- [ ] Update docs\n" +
+				"  - [X] README\n\n\t* [ ] ~~Wiki~~\n  some prose about the docs\n  - [x] ~~Site~~\n" +
+				"- not a task\n    - [ ] Under a bullet\n\n" +
+				"## Release plan ##\n\n1. [ ] Tag\n```\n- [ ] inside a code block\n```\n" +
+				"### work.backend\nSome text.\n  - [ ] After prose\n- [] not a checkbox\n",
+			expected: []todo.Task{
+				{ID: 0, Description: "Bump the version", Status: todo.StatusDone},
+				{ID: 1, Description: "Update docs", Status: todo.StatusTodo},
+				{ID: 2, Description: "README", Status: todo.StatusDone, Parent: testID(1)},
+				{ID: 3, Description: "~~Wiki~~", Status: todo.StatusTodo, Parent: testID(2)},
+				{ID: 4, Description: "Site", Status: todo.StatusCancelled, Parent: testID(1)},
+				{ID: 5, Description: "Under a bullet", Status: todo.StatusTodo},
+				{ID: 6, Description: "Tag", Status: todo.StatusTodo, Project: "Release-plan"},
+				{ID: 7, Description: "After prose", Status: todo.StatusTodo, Project: "work.backend"},
+			},
+		},
+		{name: "empty file", content: "", expected: []todo.Task{}},
+		{name: "empty item", content: "- [ ]\n", errorExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "notes.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadMarkdown(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveMarkdown(t *testing.T) {
+	list := todo.NewTaskList([]todo.Task{
+		{ID: 0, Description: "Release", Status: todo.StatusInProgress, Project: "work"},
+		{ID: 2, Description: "Groceries", Status: todo.StatusTodo},
+		{ID: 3, Description: "Changelog", Status: todo.StatusDone, Parent: testID(0)},
+		{ID: 4, Description: "Blog post", Status: todo.StatusCancelled, Parent: testID(0), Project: "blog"},
+		{ID: 5, Description: "Proofread", Status: todo.StatusTodo, Parent: testID(4)},
+		{ID: 6, Description: "Design", Status: todo.StatusTodo, Project: "home"},
+		{ID: 7, Description: "Orphan", Status: todo.StatusTodo, Parent: testID(1), Project: "work"},
+	}, 0)
+	path := filepath.Join(t.TempDir(), "tasks.md")
+	if err := SaveMarkdown(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- [ ] Groceries\n\n## home\n\n- [ ] Design\n\n## work\n\n- [ ] Release\n  - [x] Changelog\n" +
+		"  - [x] ~~Blog post~~\n    - [ ] Proofread\n- [ ] Orphan\n"
+	if string(content) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", content, expected)
+	}
+	loaded, err := LoadMarkdown(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	descriptions := []string{}
+	for _, task := range loaded.Tasks {
+		descriptions = append(descriptions, task.Description)
+	}
+	if !slices.Equal(descriptions, []string{"Groceries", "Design", "Release", "Changelog", "Blog post", "Proofread", "Orphan"}) ||
+		loaded.Tasks[4].Status != todo.StatusCancelled || !sameID(loaded.Tasks[5].Parent, testID(4)) || loaded.Tasks[6].Parent != nil {
+		t.Errorf("Test failed: unexpected tasks %+v", loaded.Tasks)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+type Opener func(path string) (Store, error)
+
+var (
+	openers    = map[string]Opener{}
+	extensions = map[string]string{}
+	// exchange marks the formats that cannot hold every field of a task, and
+	// so are only exported to and loaded from, never used as the task store.
+	exchange = map[string]bool{}
+)
+
+// Register makes a backend available under a scheme (as in "csv:///tmp/tasks.csv")
+// and, optionally, for paths ending in any of the given extensions.
+func Register(scheme string, open Opener, exts ...string) {
+	if _, ok := openers[scheme]; ok {
+		panic(fmt.Sprintf("storage: backend %q registered twice", scheme))
+	}
+	openers[scheme] = open
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = scheme
+	}
+}
+
+// RegisterExchange registers a format that loses some of the task fields:
+// it can be exported to and loaded from, but OpenStore refuses it.
+func RegisterExchange(scheme string, open Opener, exts ...string) {
+	Register(scheme, open, exts...)
+	exchange[scheme] = true
+}
+
+func Formats() []string {
+	formats := make([]string, 0, len(openers))
+	for scheme := range openers {
+		formats = append(formats, scheme)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// StoreFormats lists the formats that keep every task field.
+func StoreFormats() []string {
+	return slices.DeleteFunc(Formats(), func(format string) bool { return exchange[format] })
+}
+
+// Open resolves either a "<scheme>://<path>" URI or a plain path, in which case
+// the backend is picked by the file extension.
+func Open(uri string) (Store, error) {
+	scheme, path, err := resolve(uri, Formats())
+	if err != nil {
+		return nil, err
+	}
+	return OpenFormat(scheme, path)
+}
+
+// OpenStore is Open for the task store itself, refusing the exchange formats.
+func OpenStore(uri string) (Store, error) {
+	scheme, path, err := resolve(uri, StoreFormats())
+	if err != nil {
+		return nil, err
+	}
+	if exchange[scheme] {
+		logging.Logger.Error("Exchange format used as the task store", "format", scheme)
+		return nil, fmt.Errorf("the %s format cannot hold every task field, so it is only for export and load; "+
+			"use one of: %s", scheme, strings.Join(StoreFormats(), ", "))
+	}
+	return OpenFormat(scheme, path)
+}
+
+// resolve splits a URI into its scheme and path, or picks the scheme of a
+// plain path by its extension; formats are the ones named in the error.
+func resolve(uri string, formats []string) (string, string, error) {
+	if scheme, path, ok := strings.Cut(uri, "://"); ok {
+		return scheme, path, nil
+	}
+	scheme, ok := extensions[strings.ToLower(filepath.Ext(uri))]
+	if !ok {
+		logging.Logger.Error("No storage backend for the file extension", "path", uri)
+		return "", "", fmt.Errorf("unsupported storage file %q, expected one of the formats: %s", uri, strings.Join(formats, ", "))
+	}
+	return scheme, uri, nil
+}
+
+// Path returns the file a store URI or path refers to.
+func Path(uri string) string {
+	if _, path, ok := strings.Cut(uri, "://"); ok {
+		return path
+	}
+	return uri
+}
+
+func OpenFormat(format string, path string) (Store, error) {
+	open, ok := openers[format]
+	if !ok {
+		logging.Logger.Error("Unknown storage backend", "format", format)
+		return nil, fmt.Errorf("unknown storage format %q, expected one of: %s", format, strings.Join(Formats(), ", "))
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no path given for the %s storage", format)
+	}
+	return open(path)
+}
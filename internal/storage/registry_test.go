@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name          string
+		uri           string
+		errorExpected bool
+	}{
+		{"json by extension", filepath.Join(dir, "tasks.json"), false},
+		{"csv by extension", filepath.Join(dir, "tasks.CSV"), false},
+		{"csv by scheme", "csv://" + filepath.Join(dir, "tasks.data"), false},
+		{"unknown extension", filepath.Join(dir, "tasks.data"), true},
+		{"unknown scheme", "xml://" + filepath.Join(dir, "tasks.xml"), true},
+		{"scheme without a path", "json://", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Open(tt.uri)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && store == nil {
+				t.Error("Test failed: got a nil store without an error")
+			}
+		})
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name          string
+		uri           string
+		errorExpected bool
+	}{
+		{"json by extension", filepath.Join(dir, "tasks.json"), false},
+		{"sqlite by scheme", "sqlite://" + filepath.Join(dir, "tasks.data"), false},
+		{"exchange format by extension", filepath.Join(dir, "todo.txt"), true},
+		{"calendar by extension", filepath.Join(dir, "tasks.ics"), true},
+		{"markdown by scheme", "md://" + filepath.Join(dir, "tasks.txt"), true},
+		{"taskwarrior by scheme", "taskwarrior://" + filepath.Join(dir, "tasks.json"), true},
+		{"exchange format by scheme", "todotxt://" + filepath.Join(dir, "tasks.data"), true},
+		{"unknown extension", filepath.Join(dir, "tasks.data"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := OpenStore(tt.uri)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil {
+				store.Close()
+			}
+		})
+	}
+	if formats := StoreFormats(); !slices.Equal(formats, []string{"csv", "json", "sqlite", "toml", "yaml"}) {
+		t.Errorf("Test failed: got store formats %v, expected csv, json, sqlite, toml and yaml", formats)
+	}
+}
+
+func TestExchangeMissingFile(t *testing.T) {
+	for _, format := range []string{"todotxt", "ics", "md", "taskwarrior"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "typo")
+			store, err := OpenFormat(format, path)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if _, err := store.Load(); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Test failed: got error %v, expected a missing file", err)
+			}
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Test failed: loading created the missing file: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,528 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// sqliteMigrations are applied in order and recorded in schema_migrations, the
+// version of a migration being its index + 1. Never edit an applied migration,
+// append a new one instead.
+var sqliteMigrations = []string{
+	`CREATE TABLE tasks (
+		id          INTEGER PRIMARY KEY,
+		description TEXT    NOT NULL,
+		done        INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX tasks_done ON tasks (done);
+	CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value INTEGER NOT NULL
+	);
+	INSERT INTO meta (key, value) VALUES ('next_id', 0);`,
+	`ALTER TABLE tasks ADD COLUMN due TEXT;
+	ALTER TABLE tasks ADD COLUMN scheduled TEXT;
+	CREATE INDEX tasks_due ON tasks (due);`,
+	`ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX tasks_priority ON tasks (priority);`,
+	`ALTER TABLE tasks ADD COLUMN project TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tasks ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+	CREATE INDEX tasks_project ON tasks (project);`,
+	`ALTER TABLE tasks ADD COLUMN created_at TEXT;
+	ALTER TABLE tasks ADD COLUMN updated_at TEXT;
+	ALTER TABLE tasks ADD COLUMN completed_at TEXT;
+	CREATE INDEX tasks_created_at ON tasks (created_at);
+	CREATE INDEX tasks_completed_at ON tasks (completed_at);`,
+	`ALTER TABLE tasks ADD COLUMN parent_id INTEGER;
+	CREATE INDEX tasks_parent_id ON tasks (parent_id);`,
+	`ALTER TABLE tasks ADD COLUMN depends_on TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN annotations TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tasks ADD COLUMN notes TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN status TEXT NOT NULL DEFAULT '';
+	UPDATE tasks SET status = CASE WHEN done THEN 'done' ELSE '' END;
+	DROP INDEX tasks_done;
+	ALTER TABLE tasks DROP COLUMN done;
+	CREATE INDEX tasks_status ON tasks (status);`,
+	`ALTER TABLE tasks ADD COLUMN intervals TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN estimate TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN archived_at TEXT;
+	CREATE INDEX tasks_archived_at ON tasks (archived_at);`,
+	`ALTER TABLE tasks ADD COLUMN uuid TEXT NOT NULL DEFAULT '';`,
+}
+
+var sqliteTaskColumns = []string{
+	"id", "description", "status", "due", "scheduled", "priority", "project", "tags",
+	"created_at", "updated_at", "completed_at", "parent_id", "depends_on", "recurrence",
+	"annotations", "notes", "intervals", "estimate", "archived_at", "uuid",
+}
+
+// sqliteActive selects the rows of the tasks that are not archived.
+const sqliteActive string = "archived_at IS NULL"
+
+// filters missing here depend on the current date and are evaluated in go
+var sqliteFilterConditions = map[todo.TaskStateFilter]string{
+	todo.FilterAll:       "1 = 1",
+	todo.FilterDone:      "status = 'done'",
+	todo.FilterPending:   "status NOT IN ('done', 'cancelled')",
+	todo.FilterCancelled: "status = 'cancelled'",
+}
+
+type SQLiteStore struct {
+	path string
+	db   *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	// escaped, so that ? and # in the path are not read as the query or fragment
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	db, err := sql.Open("sqlite", "file:"+escaped+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		logging.Logger.Error("Error opening the sqlite storage", "error", err.Error(), "path", path)
+		return nil, fmt.Errorf("failed to open sqlite storage: %w", err)
+	}
+	store := &SQLiteStore{path: path, db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		logging.Logger.Error("Error creating the migrations table", "error", err.Error(), "path", s.path)
+		return fmt.Errorf("failed to prepare sqlite migrations: %w", err)
+	}
+	var current int
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("failed to read the sqlite schema version: %w", err)
+	}
+	if current > len(sqliteMigrations) {
+		return fmt.Errorf("sqlite storage schema version %d is newer than supported %d", current, len(sqliteMigrations))
+	}
+	for version := current + 1; version <= len(sqliteMigrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[version-1]); err != nil {
+			tx.Rollback()
+			logging.Logger.Error("Error applying a migration", "error", err.Error(), "version", version, "path", s.path)
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+		logging.Logger.Debug("Applied sqlite migration", "version", version, "path", s.path)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (todo.Task, error) {
+	var task todo.Task
+	var due, scheduled, createdAt, updatedAt, completedAt, archivedAt sql.NullString
+	var parent sql.NullInt64
+	var tags, dependsOn, recurrence, annotations, intervals, estimate string
+	if err := row.Scan(
+		&task.ID, &task.Description, &task.Status, &due, &scheduled, &task.Priority, &task.Project, &tags,
+		&createdAt, &updatedAt, &completedAt, &parent, &dependsOn, &recurrence, &annotations, &task.Notes,
+		&intervals, &estimate, &archivedAt, &task.UUID,
+	); err != nil {
+		return task, err
+	}
+	// rows migrated from the done flag leave open tasks to the active workflow
+	if task.Status == "" {
+		task.Status = todo.ActiveWorkflow.Initial()
+	}
+	if recurrence != "" {
+		task.Recurrence = &todo.Recurrence{}
+		if err := task.Recurrence.UnmarshalText([]byte(recurrence)); err != nil {
+			return task, fmt.Errorf("invalid recurrence of task id=%d: %w", task.ID, err)
+		}
+	}
+	if estimate != "" {
+		task.Estimate = &todo.Estimate{}
+		if err := task.Estimate.UnmarshalText([]byte(estimate)); err != nil {
+			return task, fmt.Errorf("invalid estimate of task id=%d: %w", task.ID, err)
+		}
+	}
+	if parent.Valid {
+		parentID := int(parent.Int64)
+		task.Parent = &parentID
+	}
+	if fields := strings.Fields(tags); len(fields) > 0 {
+		task.Tags = fields
+	}
+	var err error
+	if task.Annotations, err = parseAnnotations(annotations); err != nil {
+		return task, fmt.Errorf("invalid annotations of task id=%d: %w", task.ID, err)
+	}
+	if task.Intervals, err = parseIntervals(intervals); err != nil {
+		return task, fmt.Errorf("invalid intervals of task id=%d: %w", task.ID, err)
+	}
+	if task.DependsOn, err = parseSQLiteIDs(dependsOn); err != nil {
+		return task, fmt.Errorf("invalid depends_on of task id=%d: %w", task.ID, err)
+	}
+	if task.Due, err = parseSQLiteTime(due); err != nil {
+		return task, fmt.Errorf("invalid due of task id=%d: %w", task.ID, err)
+	}
+	if task.Scheduled, err = parseSQLiteTime(scheduled); err != nil {
+		return task, fmt.Errorf("invalid scheduled of task id=%d: %w", task.ID, err)
+	}
+	if task.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return task, fmt.Errorf("invalid created_at of task id=%d: %w", task.ID, err)
+	}
+	if task.UpdatedAt, err = parseSQLiteTime(updatedAt); err != nil {
+		return task, fmt.Errorf("invalid updated_at of task id=%d: %w", task.ID, err)
+	}
+	if task.CompletedAt, err = parseSQLiteTime(completedAt); err != nil {
+		return task, fmt.Errorf("invalid completed_at of task id=%d: %w", task.ID, err)
+	}
+	if task.ArchivedAt, err = parseSQLiteTime(archivedAt); err != nil {
+		return task, fmt.Errorf("invalid archived_at of task id=%d: %w", task.ID, err)
+	}
+	return task, nil
+}
+
+func taskArgs(task todo.Task) []any {
+	return []any{
+		task.ID, task.Description, task.Status, formatSQLiteTime(task.Due), formatSQLiteTime(task.Scheduled),
+		task.Priority, task.Project, strings.Join(task.Tags, " "),
+		formatSQLiteTime(task.CreatedAt), formatSQLiteTime(task.UpdatedAt), formatSQLiteTime(task.CompletedAt),
+		sqliteParentID(task.Parent), formatSQLiteIDs(task.DependsOn), formatSQLiteRecurrence(task.Recurrence),
+		formatAnnotations(task.Annotations), task.Notes, formatIntervals(task.Intervals),
+		formatSQLiteEstimate(task.Estimate), formatSQLiteTime(task.ArchivedAt), task.UUID,
+	}
+}
+
+func formatSQLiteRecurrence(value *todo.Recurrence) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func formatSQLiteEstimate(value *todo.Estimate) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
+// Dependencies are stored as space-separated ids, like tags.
+func formatSQLiteIDs(ids []int) string {
+	return strings.Trim(fmt.Sprint(ids), "[]")
+}
+
+func parseSQLiteIDs(value string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Fields(value) {
+		id, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func sqliteParentID(parent *int) sql.NullInt64 {
+	if parent == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*parent), Valid: true}
+}
+
+// Times are stored as UTC RFC 3339 text so that they sort correctly and the
+// indexes can serve range queries.
+func formatSQLiteTime(value *time.Time) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: value.UTC().Format(time.RFC3339Nano), Valid: true}
+}
+
+func parseSQLiteTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value.String)
+	if err != nil {
+		return nil, err
+	}
+	parsed = parsed.Local()
+	return &parsed, nil
+}
+
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func upsertTask(db sqlExecer, task todo.Task) error {
+	updates := make([]string, 0, len(sqliteTaskColumns)-1)
+	for _, column := range sqliteTaskColumns[1:] {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+	query := fmt.Sprintf(
+		"INSERT INTO tasks (%s) VALUES (%s) ON CONFLICT (id) DO UPDATE SET %s",
+		strings.Join(sqliteTaskColumns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(sqliteTaskColumns)), ", "),
+		strings.Join(updates, ", "),
+	)
+	if _, err := db.Exec(query, taskArgs(task)...); err != nil {
+		logging.Logger.Error("Error writing a task row", "error", err.Error(), "task", task)
+		return fmt.Errorf("failed to write task id=%d: %w", task.ID, err)
+	}
+	return nil
+}
+
+func bumpNextID(db sqlExecer, nextID int) error {
+	if _, err := db.Exec("UPDATE meta SET value = MAX(value, ?) WHERE key = 'next_id'", nextID); err != nil {
+		return fmt.Errorf("failed to update next id: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) queryTasks(where string, args ...any) ([]todo.Task, error) {
+	rows, err := s.db.Query(
+		fmt.Sprintf("SELECT %s FROM tasks WHERE %s ORDER BY id", strings.Join(sqliteTaskColumns, ", "), where),
+		args...,
+	)
+	if err != nil {
+		logging.Logger.Error("Error querying tasks", "error", err.Error(), "path", s.path)
+		return []todo.Task{}, fmt.Errorf("failed to query sqlite storage: %w", err)
+	}
+	defer rows.Close()
+	tasks := []todo.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return []todo.Task{}, fmt.Errorf("failed to read a task row: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *SQLiteStore) Load() (todo.TaskList, error) {
+	tasks, err := s.queryTasks(sqliteActive)
+	if err != nil {
+		return todo.TaskList{Tasks: []todo.Task{}}, err
+	}
+	archived, err := s.queryTasks("NOT " + sqliteActive)
+	if err != nil {
+		return todo.TaskList{Tasks: []todo.Task{}}, err
+	}
+	var nextID int
+	if err := s.db.QueryRow("SELECT value FROM meta WHERE key = 'next_id'").Scan(&nextID); err != nil {
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read next id: %w", err)
+	}
+	if len(archived) == 0 {
+		archived = nil
+	}
+	list := todo.TaskList{NextID: nextID, Tasks: tasks, Archived: archived}.Normalize()
+	if err := todo.Validate(list); err != nil {
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid sqlite storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from sqlite", "amount", len(list.Tasks), "archived", len(list.Archived), "next_id", list.NextID)
+	return list, nil
+}
+
+// Save synchronises the table with the list in one transaction, touching only
+// the rows that actually changed.
+func (s *SQLiteStore) Save(list todo.TaskList) error {
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		return fmt.Errorf("refusing to save an invalid task list: %w", err)
+	}
+	existing, err := s.queryTasks("1 = 1")
+	if err != nil {
+		return err
+	}
+	stale := make(map[int]todo.Task, len(existing))
+	for _, task := range existing {
+		stale[task.ID] = task
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start a sqlite transaction: %w", err)
+	}
+	defer tx.Rollback()
+	written := 0
+	for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+		previous, ok := stale[task.ID]
+		delete(stale, task.ID)
+		if ok && reflect.DeepEqual(taskArgs(previous), taskArgs(task)) {
+			continue
+		}
+		if err := upsertTask(tx, task); err != nil {
+			return err
+		}
+		written++
+	}
+	for id := range stale {
+		if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+			return fmt.Errorf("failed to delete task id=%d: %w", id, err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE meta SET value = ? WHERE key = 'next_id'", list.NextID); err != nil {
+		return fmt.Errorf("failed to update next id: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		logging.Logger.Error("Error committing to the sqlite storage", "error", err.Error(), "path", s.path)
+		return fmt.Errorf("failed to save to sqlite storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to sqlite", "written", written, "deleted", len(stale), "next_id", list.NextID)
+	return nil
+}
+
+func (s *SQLiteStore) Get(id int) (todo.Task, error) {
+	task, err := scanTask(s.db.QueryRow(
+		fmt.Sprintf("SELECT %s FROM tasks WHERE id = ? AND %s", strings.Join(sqliteTaskColumns, ", "), sqliteActive), id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		logging.Logger.Error("Could not find a task with specified id", "id", id, "path", s.path)
+		return todo.Task{}, fmt.Errorf("task with requested id=%d is missing", id)
+	}
+	if err != nil {
+		return todo.Task{}, fmt.Errorf("failed to read task id=%d: %w", id, err)
+	}
+	return task, nil
+}
+
+func (s *SQLiteStore) Put(task todo.Task) error {
+	if task.ID < 0 {
+		return fmt.Errorf("refusing to store task with a negative id=%d", task.ID)
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start a sqlite transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := upsertTask(tx, task); err != nil {
+		return err
+	}
+	if err := bumpNextID(tx, task.ID+1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes a single task; its subtasks become top-level tasks and
+// dependencies on it are dropped.
+func (s *SQLiteStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start a sqlite transaction: %w", err)
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec("DELETE FROM tasks WHERE id = ? AND "+sqliteActive, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task id=%d: %w", id, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		logging.Logger.Error("Could not find a task with specified id", "id", id, "path", s.path)
+		return fmt.Errorf("task with requested id=%d is missing", id)
+	}
+	now := todo.Now()
+	if _, err := tx.Exec(
+		"UPDATE tasks SET parent_id = NULL, updated_at = ? WHERE parent_id = ?", formatSQLiteTime(&now), id,
+	); err != nil {
+		return fmt.Errorf("failed to orphan the subtasks of task id=%d: %w", id, err)
+	}
+	if err := dropDependency(tx, id, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func dropDependency(tx *sql.Tx, id int, now time.Time) error {
+	rows, err := tx.Query("SELECT id, depends_on FROM tasks WHERE depends_on != ''")
+	if err != nil {
+		return fmt.Errorf("failed to read dependencies: %w", err)
+	}
+	updated := map[int]string{}
+	for rows.Next() {
+		var taskID int
+		var value string
+		if err := rows.Scan(&taskID, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read dependencies: %w", err)
+		}
+		dependsOn, err := parseSQLiteIDs(value)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("invalid depends_on of task id=%d: %w", taskID, err)
+		}
+		if slices.Contains(dependsOn, id) {
+			updated[taskID] = formatSQLiteIDs(slices.DeleteFunc(dependsOn, func(dependency int) bool { return dependency == id }))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read dependencies: %w", err)
+	}
+	for taskID, dependsOn := range updated {
+		if _, err := tx.Exec(
+			"UPDATE tasks SET depends_on = ?, updated_at = ? WHERE id = ?", dependsOn, formatSQLiteTime(&now), taskID,
+		); err != nil {
+			return fmt.Errorf("failed to drop the dependency on task id=%d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) List(filter string) ([]todo.Task, error) {
+	where, ok := sqliteFilterConditions[todo.TaskStateFilter(filter)]
+	if !ok {
+		list, err := s.Load()
+		if err != nil {
+			return []todo.Task{}, err
+		}
+		return todo.List(list.Tasks, filter), nil
+	}
+	return s.queryTasks(sqliteActive + " AND " + where)
+}
+
+func (s *SQLiteStore) Lock(timeout time.Duration) (func() error, error) {
+	return LockFile(s.path+".lock", timeout)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func init() {
+	Register("sqlite", func(path string) (Store, error) {
+		store, err := OpenSQLite(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}, ".db", ".sqlite", ".sqlite3")
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func openTestSQLite(t *testing.T, path string) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("Test failed: couldn't open sqlite storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	store := openTestSQLite(t, path)
+	var version int
+	if err := store.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if version != len(sqliteMigrations) {
+		t.Errorf("Test failed: schema version %d, expected %d", version, len(sqliteMigrations))
+	}
+	if err := store.Put(todo.Task{ID: 3, Description: "Task A", Status: todo.StatusTodo}); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	store.Close()
+
+	reopened := openTestSQLite(t, path)
+	list, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if len(list.Tasks) != 1 || list.NextID != 4 {
+		t.Errorf("Test failed: data lost on reopening, got %v", list)
+	}
+}
+
+func TestSQLiteSpecialPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my tasks?v=2#1 100%.db")
+	store := openTestSQLite(t, path)
+	if err := store.Put(todo.Task{ID: 0, Description: "Task A", Status: todo.StatusTodo}); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Test failed: the database is not at %q: %v", path, err)
+	}
+}
+
+func TestSQLiteLegacyDoneMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	legacy := 9 // the last version with the done column
+	statements := []string{"CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT)"}
+	for version := 1; version <= legacy; version++ {
+		statements = append(statements, sqliteMigrations[version-1], fmt.Sprintf("INSERT INTO schema_migrations (version) VALUES (%d)", version))
+	}
+	statements = append(statements,
+		"INSERT INTO tasks (id, description, done) VALUES (0, 'Task A', 0), (1, 'Task B', 1)",
+		"UPDATE meta SET value = 2 WHERE key = 'next_id'",
+	)
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Test failed: couldn't prepare a legacy storage: %v", err)
+		}
+	}
+	db.Close()
+
+	list, err := openTestSQLite(t, path).Load()
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if len(list.Tasks) != 2 || list.Tasks[0].Status != todo.StatusTodo || list.Tasks[1].Status != todo.StatusDone {
+		t.Errorf("Test failed: unexpected statuses after migrating %v", list.Tasks)
+	}
+
+	// open tasks get the initial status of the workflow in use
+	workflow, err := todo.ParseWorkflow("backlog > doing, done, cancelled; doing > done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.ActiveWorkflow = workflow
+	defer func() { todo.ActiveWorkflow = todo.DefaultWorkflow }()
+	if list, err = openTestSQLite(t, path).Load(); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if list.Tasks[0].Status != "backlog" || list.Tasks[1].Status != todo.StatusDone {
+		t.Errorf("Test failed: unexpected statuses under another workflow %v", list.Tasks)
+	}
+}
+
+func TestSQLiteSave(t *testing.T) {
+	store := openTestSQLite(t, filepath.Join(t.TempDir(), "tasks.db"))
+	initial := todo.NewTaskList([]todo.Task{
+		{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+		{ID: 1, Description: "Task B", Status: todo.StatusDone},
+		{ID: 2, Description: "Task C", Status: todo.StatusTodo},
+	}, 5)
+	if err := store.Save(initial); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	updated := todo.NewTaskList([]todo.Task{
+		{
+			ID: 0, Description: "Task A", Status: todo.StatusDone, Priority: todo.PriorityHigh, Project: "work", Tags: []string{"a", "b"},
+			Estimate: &todo.Estimate{Amount: 2.25, Unit: todo.Hours}, UUID: "1f2d3c4b-0000-4000-8000-000000000001",
+		},
+		{
+			ID: 2, Description: "Task C", Status: todo.StatusTodo, Due: testDate(2026, 10, 20), Parent: testID(0), DependsOn: []int{0},
+			Recurrence:  testRecurrence("monthly"),
+			Annotations: []todo.Annotation{{Time: *testTime("2026-10-18T09:30:00Z"), Text: "a\\nb\nc"}},
+			Notes:       "line 1\nline 2",
+			Intervals:   []todo.Interval{{Start: *testTime("2026-10-18T09:00:00Z"), End: testTime("2026-10-18T10:00:00Z")}, {Start: *testTime("2026-10-19T09:00:00Z")}},
+			CreatedAt:   testTime("2026-10-01T09:30:00.5+02:00"),
+		},
+	}, 5)
+	if err := store.Save(updated); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if loaded.NextID != 5 {
+		t.Errorf("Test failed: expected NextID 5, got %d", loaded.NextID)
+	}
+	if len(loaded.Tasks) != len(updated.Tasks) {
+		t.Fatalf("Test failed: saved %d tasks, but loaded %d", len(updated.Tasks), len(loaded.Tasks))
+	}
+	for i := range loaded.Tasks {
+		if !sameTask(loaded.Tasks[i], updated.Tasks[i]) {
+			t.Errorf("Test failed: saved task %d = %v, but loaded %v", i, updated.Tasks[i], loaded.Tasks[i])
+		}
+	}
+}
+
+func TestSQLiteRowOperations(t *testing.T) {
+	store := openTestSQLite(t, filepath.Join(t.TempDir(), "tasks.db"))
+	for _, task := range []todo.Task{
+		{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+		{ID: 1, Description: "Task B", Status: todo.StatusDone},
+	} {
+		if err := store.Put(task); err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+	}
+	if err := store.Put(todo.Task{ID: 0, Description: "Task A", Status: todo.StatusDone}); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	task, err := store.Get(0)
+	if err != nil || task.Status != todo.StatusDone {
+		t.Errorf("Test failed: got %v (%v) after an update", task, err)
+	}
+	if _, err := store.Get(7); err == nil {
+		t.Error("Test failed: Expected an error for a missing task")
+	}
+
+	done, err := store.List(string(todo.FilterDone))
+	if err != nil || len(done) != 2 {
+		t.Errorf("Test failed: expected 2 done tasks, got %v (%v)", done, err)
+	}
+	if err := store.Delete(1); err != nil {
+		t.Errorf("Test failed: Unexpected error: %v", err)
+	}
+	if err := store.Delete(1); err == nil {
+		t.Error("Test failed: Expected an error deleting a missing task")
+	}
+	pending, err := store.List(string(todo.FilterPending))
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Test failed: expected no pending tasks, got %v (%v)", pending, err)
+	}
+
+	if err := store.Put(todo.Task{ID: 2, Description: "Task C", Status: todo.StatusTodo, Parent: testID(0), DependsOn: []int{0}}); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if err := store.Delete(0); err != nil {
+		t.Errorf("Test failed: Unexpected error: %v", err)
+	}
+	if task, err := store.Get(2); err != nil || task.Parent != nil || task.DependsOn != nil {
+		t.Errorf("Test failed: expected the references to the deleted task to be dropped, got %v (%v)", task, err)
+	}
+}
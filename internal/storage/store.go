@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+type Store interface {
+	Load() (todo.TaskList, error)
+	Save(list todo.TaskList) error
+	Get(id int) (todo.Task, error)
+	Put(task todo.Task) error
+	// Delete removes a single task, its subtasks become top-level tasks.
+	Delete(id int) error
+	List(filter string) ([]todo.Task, error)
+	// Lock guards a whole load-modify-save cycle against other processes
+	// working on the same store; call the returned function to release it.
+	Lock(timeout time.Duration) (func() error, error)
+	Close() error
+}
+
+// fileStore adapts a pair of whole-file codecs to the Store interface. Every
+// single-task operation is a full load-modify-save cycle.
+type fileStore struct {
+	path string
+	load func(string) (todo.TaskList, error)
+	save func(string, todo.TaskList) error
+}
+
+func NewFileStore(path string, load func(string) (todo.TaskList, error), save func(string, todo.TaskList) error) Store {
+	return &fileStore{path: path, load: load, save: save}
+}
+
+func (s *fileStore) Load() (todo.TaskList, error) {
+	return s.load(s.path)
+}
+
+func (s *fileStore) Save(list todo.TaskList) error {
+	return s.save(s.path, list)
+}
+
+func (s *fileStore) Get(id int) (todo.Task, error) {
+	list, err := s.load(s.path)
+	if err != nil {
+		return todo.Task{}, err
+	}
+	for _, task := range list.Tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	logging.Logger.Error("Could not find a task with specified id", "id", id, "path", s.path)
+	return todo.Task{}, fmt.Errorf("task with requested id=%d is missing", id)
+}
+
+func (s *fileStore) Put(task todo.Task) error {
+	list, err := s.load(s.path)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range list.Tasks {
+		if list.Tasks[i].ID == task.ID {
+			list.Tasks[i] = task
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		list.Tasks = append(list.Tasks, task)
+	}
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		return fmt.Errorf("refusing to store task id=%d: %w", task.ID, err)
+	}
+	return s.save(s.path, list)
+}
+
+func (s *fileStore) Delete(id int) error {
+	list, err := s.load(s.path)
+	if err != nil {
+		return err
+	}
+	updatedTasks, err := todo.Delete(list.Tasks, id, todo.ChildrenOrphan)
+	if err != nil {
+		return err
+	}
+	list.Tasks = updatedTasks
+	return s.save(s.path, list)
+}
+
+func (s *fileStore) List(filter string) ([]todo.Task, error) {
+	list, err := s.load(s.path)
+	if err != nil {
+		return []todo.Task{}, err
+	}
+	return todo.List(list.Tasks, filter), nil
+}
+
+func (s *fileStore) Lock(timeout time.Duration) (func() error, error) {
+	return LockFile(s.path+".lock", timeout)
+}
+
+func (s *fileStore) Close() error {
+	return nil
+}
+
+// defaultStatuses gives hand-written tasks without a status the initial one.
+func defaultStatuses(list todo.TaskList) todo.TaskList {
+	for _, tasks := range [][]todo.Task{list.Tasks, list.Archived} {
+		for i := range tasks {
+			if tasks[i].Status == "" {
+				tasks[i].Status = todo.ActiveWorkflow.Initial()
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestFileStore(t *testing.T) {
+	for _, format := range []string{"json", "csv", "yaml", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			store, err := OpenFormat(format, filepath.Join(t.TempDir(), "tasks."+format))
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if err := store.Save(todo.NewTaskList([]todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+				{ID: 1, Description: "Task B", Status: todo.StatusDone},
+			}, 0)); err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+
+			if err := store.Put(todo.Task{ID: 0, Description: "Task A edited", Status: todo.StatusDone}); err != nil {
+				t.Errorf("Test failed: Unexpected error on update: %v", err)
+			}
+			if err := store.Put(todo.Task{ID: 5, Description: "Task C", Status: todo.StatusTodo}); err != nil {
+				t.Errorf("Test failed: Unexpected error on insert: %v", err)
+			}
+			task, err := store.Get(0)
+			if err != nil || task.Description != "Task A edited" || task.Status != todo.StatusDone {
+				t.Errorf("Test failed: got %v (%v) after an update", task, err)
+			}
+			if _, err := store.Get(42); err == nil {
+				t.Error("Test failed: Expected an error for a missing task")
+			}
+
+			if err := store.Delete(1); err != nil {
+				t.Errorf("Test failed: Unexpected error on delete: %v", err)
+			}
+			if err := store.Delete(1); err == nil {
+				t.Error("Test failed: Expected an error deleting a missing task")
+			}
+
+			pending, err := store.List(string(todo.FilterPending))
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if len(pending) != 1 || pending[0].ID != 5 {
+				t.Errorf("Test failed: unexpected pending tasks %v", pending)
+			}
+
+			list, err := store.Load()
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if list.NextID != 6 {
+				t.Errorf("Test failed: expected NextID 6 after inserting id 5, got %d", list.NextID)
+			}
+		})
+	}
+}
+
+func TestArchivedTasks(t *testing.T) {
+	for _, format := range []string{"json", "csv", "sqlite", "yaml", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			store, err := OpenFormat(format, filepath.Join(t.TempDir(), "tasks."+format))
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			defer store.Close()
+			saved := todo.NewTaskList([]todo.Task{
+				{ID: 0, Description: "Task A", Status: todo.StatusTodo},
+			}, 0)
+			saved.Archived = []todo.Task{
+				{ID: 1, Description: "Task B", Status: todo.StatusDone, ArchivedAt: testTime("2026-10-18T09:00:00Z")},
+				{ID: 2, Description: "Task C", Status: todo.StatusDone, Parent: testID(1), ArchivedAt: testTime("2026-10-18T09:00:00Z")},
+			}
+			saved = saved.Normalize()
+			if err := store.Save(saved); err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+
+			list, err := store.Load()
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if list.NextID != 3 || len(list.Tasks) != 1 || !slices.EqualFunc(list.Archived, saved.Archived, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list, saved)
+			}
+			if _, err := store.Get(1); err == nil {
+				t.Error("Test failed: Get returned an archived task")
+			}
+			all, err := store.List(string(todo.FilterAll))
+			if err != nil || len(all) != 1 {
+				t.Errorf("Test failed: List returned archived tasks: %v (%v)", all, err)
+			}
+		})
+	}
+}
+
+func testDate(year int, month time.Month, day int) *time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	return &date
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func testID(id int) *int {
+	return &id
+}
+
+func sameTask(a, b todo.Task) bool {
+	return a.ID == b.ID &&
+		a.Description == b.Description &&
+		a.Status == b.Status &&
+		sameTime(a.Due, b.Due) &&
+		sameTime(a.Scheduled, b.Scheduled) &&
+		a.Priority == b.Priority &&
+		reflect.DeepEqual(a.Estimate, b.Estimate) &&
+		a.Project == b.Project &&
+		slices.Equal(a.Tags, b.Tags) &&
+		sameID(a.Parent, b.Parent) &&
+		slices.Equal(a.DependsOn, b.DependsOn) &&
+		reflect.DeepEqual(a.Recurrence, b.Recurrence) &&
+		sameAnnotations(a.Annotations, b.Annotations) &&
+		a.Notes == b.Notes &&
+		sameIntervals(a.Intervals, b.Intervals) &&
+		sameTime(a.CreatedAt, b.CreatedAt) &&
+		sameTime(a.UpdatedAt, b.UpdatedAt) &&
+		sameTime(a.CompletedAt, b.CompletedAt) &&
+		sameTime(a.ArchivedAt, b.ArchivedAt) &&
+		a.UUID == b.UUID
+}
+
+func sameAnnotations(a, b []todo.Annotation) bool {
+	return slices.EqualFunc(a, b, func(x, y todo.Annotation) bool { return x.Time.Equal(y.Time) && x.Text == y.Text })
+}
+
+func sameIntervals(a, b []todo.Interval) bool {
+	return slices.EqualFunc(a, b, func(x, y todo.Interval) bool { return x.Start.Equal(y.Start) && sameTime(x.End, y.End) })
+}
+
+func testRecurrence(value string) *todo.Recurrence {
+	rule, err := todo.ParseRecurrence(value)
+	if err != nil {
+		panic(err)
+	}
+	return &rule
+}
+
+func testTime(value string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		panic(err)
+	}
+	return &parsed
+}
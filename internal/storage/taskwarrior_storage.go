@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+const (
+	// taskwarriorUUIDPrefix is followed by the task id in 12 hex digits, so the
+	// UUID of a task not imported from Taskwarrior is the same on every export
+	// and maps back to its id
+	taskwarriorUUIDPrefix = "6f9c1e2a-5b7d-8e43-9a1c-"
+	taskwarriorTimeLayout = "20060102T150405Z"
+)
+
+// Taskwarrior statuses; recurring is the status of the template that pending
+// occurrences are generated from.
+const (
+	taskwarriorPending   = "pending"
+	taskwarriorWaiting   = "waiting"
+	taskwarriorCompleted = "completed"
+	taskwarriorDeleted   = "deleted"
+	taskwarriorRecurring = "recurring"
+)
+
+// taskwarriorPriorities are the Taskwarrior priorities, lowest first.
+var taskwarriorPriorities = []string{"L", "M", "H"}
+
+// taskwarriorTask holds the fields of a `task export` object the codec
+// understands; the others, such as urgency, are ignored.
+type taskwarriorTask struct {
+	ID          int                     `json:"id"`
+	UUID        string                  `json:"uuid"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"`
+	Entry       string                  `json:"entry,omitempty"`
+	Modified    string                  `json:"modified,omitempty"`
+	End         string                  `json:"end,omitempty"`
+	Due         string                  `json:"due,omitempty"`
+	Scheduled   string                  `json:"scheduled,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Priority    string                  `json:"priority,omitempty"`
+	Annotations []taskwarriorAnnotation `json:"annotations,omitempty"`
+	Depends     taskwarriorDepends      `json:"depends,omitempty"`
+}
+
+type taskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// taskwarriorDepends lists the UUIDs a task depends on. Taskwarrior before 2.6
+// exports them as one comma-separated string.
+type taskwarriorDepends []string
+
+func (d *taskwarriorDepends) UnmarshalJSON(data []byte) error {
+	var joined string
+	if err := json.Unmarshal(data, &joined); err == nil {
+		*d = nil
+		if joined != "" {
+			*d = strings.Split(joined, ",")
+		}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(d))
+}
+
+// TaskwarriorStore is the store of a `task export` file. Load gives the
+// tasks whose UUID is in Known the id it maps them to, when that id is free,
+// so that loading a file again keeps the ids of the tasks.
+type TaskwarriorStore struct {
+	Store
+	Known map[string]int
+}
+
+func OpenTaskwarrior(path string) *TaskwarriorStore {
+	store := &TaskwarriorStore{Known: map[string]int{}}
+	store.Store = NewFileStore(path, func(path string) (todo.TaskList, error) {
+		return loadTaskwarrior(path, store.Known)
+	}, SaveTaskwarrior)
+	return store
+}
+
+// LoadTaskwarrior reads the JSON array written by `task export`. Tasks keep
+// the id their UUID was exported with, the others get the ids following the
+// largest one in the file. Recurring templates are skipped, their pending
+// occurrences are loaded as tasks of their own. Unlike a task store, a missing
+// file is an error.
+func LoadTaskwarrior(path string) (todo.TaskList, error) {
+	return loadTaskwarrior(path, nil)
+}
+
+// loadTaskwarrior gives the known UUIDs their id when it is free.
+func loadTaskwarrior(path string, known map[string]int) (todo.TaskList, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the taskwarrior storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read taskwarrior storage: %w", err)
+	}
+	exported := []taskwarriorTask{}
+	if len(bytes.TrimSpace(fileBytes)) > 0 {
+		if err := json.Unmarshal(fileBytes, &exported); err != nil {
+			logging.Logger.Error("Error unmarshalling the taskwarrior storage file", "error", err.Error(), "file", path)
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to parse taskwarrior json: %w", err)
+		}
+	}
+	exported = slices.DeleteFunc(exported, func(task taskwarriorTask) bool {
+		if task.Status == taskwarriorRecurring {
+			logging.Logger.Warn("Skipping a recurring Taskwarrior template", "uuid", task.UUID, "description", task.Description)
+		}
+		return task.Status == taskwarriorRecurring
+	})
+
+	ids := taskwarriorIDs(exported, known)
+	list := todo.TaskList{Tasks: []todo.Task{}}
+	for _, entry := range exported {
+		task, err := unmarshalTaskwarriorTask(entry, ids)
+		if err != nil {
+			logging.Logger.Error("Error converting a Taskwarrior task", "error", err.Error(), "uuid", entry.UUID, "file", path)
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid taskwarrior task %s: %w", entry.UUID, err)
+		}
+		list.Tasks = append(list.Tasks, task)
+	}
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid taskwarrior storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid taskwarrior storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from taskwarrior", "amount", len(list.Tasks), "next_id", list.NextID)
+	return list, nil
+}
+
+// SaveTaskwarrior writes the tasks in the `task export` format, one per line.
+// Taskwarrior has no place for subtasks, estimates, recurrence rules, notes,
+// tracked time or the archive, so archived tasks are written like the others.
+func SaveTaskwarrior(path string, list todo.TaskList) error {
+	tasks := append(slices.Clone(list.Tasks), list.Archived...)
+	// dependencies refer to the UUIDs the tasks were imported with
+	uuids := map[int]string{}
+	for _, task := range tasks {
+		if task.UUID != "" {
+			uuids[task.ID] = task.UUID
+		}
+	}
+	lines := []string{}
+	for _, task := range tasks {
+		line, err := json.Marshal(marshalTaskwarriorTask(task, uuids))
+		if err != nil {
+			logging.Logger.Error("Error marshalling tasks to taskwarrior json", "error", err.Error(), "id", task.ID)
+			return fmt.Errorf("failed to dump taskwarrior json: %w", err)
+		}
+		lines = append(lines, string(line))
+	}
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "[\n"+strings.Join(lines, ",\n")+"\n]\n")
+		return err
+	}); err != nil {
+		logging.Logger.Error("Error saving the taskwarrior storage", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to save taskwarrior storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to taskwarrior", "amount", len(lines))
+	return nil
+}
+
+// taskwarriorIDs is the table mapping each UUID to the id of its task: the
+// id it was derived from, the id it has in known, or the next free one.
+func taskwarriorIDs(exported []taskwarriorTask, known map[string]int) map[string]int {
+	ids, taken := map[string]int{}, map[int]bool{}
+	nextID := 0
+	for _, task := range exported {
+		if id, ok := parseTaskwarriorUUID(task.UUID); ok {
+			ids[task.UUID], taken[id] = id, true
+			nextID = max(nextID, id+1)
+		}
+	}
+	for _, task := range exported {
+		if id, ok := known[task.UUID]; ok && !taken[id] {
+			ids[task.UUID], taken[id] = id, true
+			nextID = max(nextID, id+1)
+		}
+	}
+	for _, task := range exported {
+		if _, ok := ids[task.UUID]; !ok {
+			ids[task.UUID] = nextID
+			logging.Logger.Debug("Numbered a Taskwarrior task", "uuid", task.UUID, "id", nextID)
+			nextID++
+		}
+	}
+	return ids
+}
+
+func marshalTaskwarriorTask(task todo.Task, uuids map[int]string) taskwarriorTask {
+	exported := taskwarriorTask{
+		UUID:        taskwarriorUUID(task.ID, uuids),
+		Description: task.Description,
+		Project:     task.Project,
+		Tags:        task.Tags,
+		Entry:       formatTaskwarriorTime(task.CreatedAt),
+		Modified:    formatTaskwarriorTime(task.UpdatedAt),
+		Due:         formatTaskwarriorTime(task.Due),
+		Scheduled:   formatTaskwarriorTime(task.Scheduled),
+	}
+	switch {
+	case task.Status == todo.StatusCancelled:
+		exported.Status = taskwarriorDeleted
+		exported.End = formatTaskwarriorTime(task.UpdatedAt)
+	case task.Status.Closed():
+		exported.Status = taskwarriorCompleted
+		exported.End = formatTaskwarriorTime(task.CompletedAt)
+	default:
+		// like Taskwarrior, only open tasks have an id
+		exported.Status = taskwarriorPending
+		exported.ID = task.ID
+	}
+	// the entry time is required
+	if exported.Entry == "" {
+		now := todo.Now()
+		exported.Entry = formatTaskwarriorTime(&now)
+	}
+	if rank := task.Priority.Rank(len(taskwarriorPriorities)); rank > 0 {
+		exported.Priority = taskwarriorPriorities[rank-1]
+	}
+	for _, annotation := range task.Annotations {
+		exported.Annotations = append(exported.Annotations, taskwarriorAnnotation{
+			Entry:       formatTaskwarriorTime(&annotation.Time),
+			Description: annotation.Text,
+		})
+	}
+	for _, id := range task.DependsOn {
+		exported.Depends = append(exported.Depends, taskwarriorUUID(id, uuids))
+	}
+	return exported
+}
+
+func unmarshalTaskwarriorTask(exported taskwarriorTask, ids map[string]int) (todo.Task, error) {
+	task := todo.Task{ID: ids[exported.UUID], Description: strings.TrimSpace(exported.Description), Project: exported.Project}
+	// derived UUIDs are made again on export
+	if _, derived := parseTaskwarriorUUID(exported.UUID); !derived {
+		task.UUID = exported.UUID
+	}
+	if task.Description == "" {
+		return task, errors.New("the task has no description")
+	}
+	switch exported.Status {
+	case taskwarriorPending, taskwarriorWaiting, "":
+		task.Status = todo.ActiveWorkflow.Initial()
+	case taskwarriorCompleted:
+		task.Status = todo.StatusDone
+	case taskwarriorDeleted:
+		task.Status = todo.StatusCancelled
+	default:
+		return task, fmt.Errorf("unknown status %q", exported.Status)
+	}
+	var err error
+	for _, field := range []struct {
+		name   string
+		value  string
+		target **time.Time
+	}{
+		{"entry", exported.Entry, &task.CreatedAt},
+		{"modified", exported.Modified, &task.UpdatedAt},
+		{"due", exported.Due, &task.Due},
+		{"scheduled", exported.Scheduled, &task.Scheduled},
+	} {
+		if *field.target, err = parseTaskwarriorTime(field.value); err != nil {
+			return task, fmt.Errorf("invalid %s: %w", field.name, err)
+		}
+	}
+	// due and scheduled dates are kept as the start of their local day
+	for _, date := range []*time.Time{task.Due, task.Scheduled} {
+		if date != nil {
+			*date = todo.StartOfDay(date.Local())
+		}
+	}
+	if task.Status == todo.StatusDone {
+		if task.CompletedAt, err = parseTaskwarriorTime(exported.End); err != nil {
+			return task, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+	switch strings.ToUpper(exported.Priority) {
+	case "H":
+		task.Priority = todo.PriorityHigh
+	case "M":
+		task.Priority = todo.PriorityMedium
+	case "L":
+		task.Priority = todo.PriorityLow
+	case "":
+	default:
+		return task, fmt.Errorf("invalid priority %q", exported.Priority)
+	}
+	if len(exported.Tags) > 0 {
+		if task.Tags, err = todo.NormalizeTags(exported.Tags); err != nil {
+			return task, err
+		}
+	}
+	for _, annotation := range exported.Annotations {
+		entry, err := parseTaskwarriorTime(annotation.Entry)
+		if err != nil || entry == nil {
+			return task, fmt.Errorf("invalid annotation entry %q", annotation.Entry)
+		}
+		task.Annotations = append(task.Annotations, todo.Annotation{Time: *entry, Text: annotation.Description})
+	}
+	for _, uuid := range exported.Depends {
+		id, ok := ids[strings.TrimSpace(uuid)]
+		if !ok {
+			// dependencies on purged or filtered out tasks are dropped
+			logging.Logger.Warn("Dropping a dependency on a task missing from the file", "uuid", exported.UUID, "depends", uuid)
+			continue
+		}
+		task.DependsOn = append(task.DependsOn, id)
+	}
+	slices.Sort(task.DependsOn)
+	task.DependsOn = slices.Compact(task.DependsOn)
+	return task, nil
+}
+
+// taskwarriorUUID is the UUID the task with the id was imported with, if
+// any, or the one derived from the id.
+func taskwarriorUUID(id int, uuids map[int]string) string {
+	if uuid, ok := uuids[id]; ok {
+		return uuid
+	}
+	return fmt.Sprintf("%s%012x", taskwarriorUUIDPrefix, id)
+}
+
+func parseTaskwarriorUUID(uuid string) (int, bool) {
+	digits, ok := strings.CutPrefix(strings.ToLower(uuid), taskwarriorUUIDPrefix)
+	if !ok || len(digits) != 12 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(digits, 16, 64)
+	return int(id), err == nil
+}
+
+func formatTaskwarriorTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(taskwarriorTimeLayout)
+}
+
+func parseTaskwarriorTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(taskwarriorTimeLayout, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func init() {
+	// Taskwarrior files are plain .json, so the format is only chosen by name
+	RegisterExchange("taskwarrior", func(path string) (Store, error) {
+		return OpenTaskwarrior(path), nil
+	})
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestLoadTaskwarrior(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "task export",
+			content: `[
+{"id":1,"description":"Call vendor","due":"20261020T000000Z","entry":"20261001T090000Z","modified":"20261002T090000Z","project":"work","status":"pending","tags":["phone","Phone"],"uuid":"1f2d3c4b-0000-4000-8000-000000000001","urgency":8.2,"priority":"H","depends":["1f2d3c4b-0000-4000-8000-000000000002","0a0a0a0a-0000-4000-8000-00000000dead"]},
+{"id":0,"description":"Get a quote","end":"20261003T100000Z","entry":"20261001T090000Z","status":"completed","uuid":"1f2d3c4b-0000-4000-8000-000000000002","annotations":[{"entry":"20261002T100000Z","description":"sent by mail"}]},
+{"id":0,"description":"Old plan","entry":"20261001T090000Z","status":"deleted","uuid":"6f9c1e2a-5b7d-8e43-9a1c-000000000007","priority":"L"},
+{"id":2,"description":"Water plants","entry":"20261001T090000Z","status":"recurring","recur":"weekly","uuid":"1f2d3c4b-0000-4000-8000-000000000003"},
+{"id":3,"description":"Someday","entry":"20261001T090000Z","status":"waiting","wait":"20261101T000000Z","uuid":"1f2d3c4b-0000-4000-8000-000000000004","depends":"1f2d3c4b-0000-4000-8000-000000000002,6f9c1e2a-5b7d-8e43-9a1c-000000000007"}
+]`,
+			expected: []todo.Task{
+				{
+					ID: 8, Description: "Call vendor", Status: todo.StatusTodo, Priority: todo.PriorityHigh, Project: "work",
+					UUID: "1f2d3c4b-0000-4000-8000-000000000001",
+					Tags: []string{"phone", "Phone"}, Due: testDate(2026, 10, 20), DependsOn: []int{9},
+					CreatedAt: testTime("2026-10-01T09:00:00Z"), UpdatedAt: testTime("2026-10-02T09:00:00Z"),
+				},
+				{
+					ID: 9, Description: "Get a quote", Status: todo.StatusDone, UUID: "1f2d3c4b-0000-4000-8000-000000000002",
+					CreatedAt: testTime("2026-10-01T09:00:00Z"), CompletedAt: testTime("2026-10-03T10:00:00Z"),
+					Annotations: []todo.Annotation{{Time: *testTime("2026-10-02T10:00:00Z"), Text: "sent by mail"}},
+				},
+				{ID: 7, Description: "Old plan", Status: todo.StatusCancelled, Priority: todo.PriorityLow, CreatedAt: testTime("2026-10-01T09:00:00Z")},
+				{
+					ID: 10, Description: "Someday", Status: todo.StatusTodo, DependsOn: []int{7, 9}, CreatedAt: testTime("2026-10-01T09:00:00Z"),
+					UUID: "1f2d3c4b-0000-4000-8000-000000000004",
+				},
+			},
+		},
+		{name: "empty file", content: "", expected: []todo.Task{}},
+		{name: "empty export", content: "[\n]\n", expected: []todo.Task{}},
+		{name: "unknown status", content: `[{"uuid":"a","description":"a","status":"started"}]`, errorExpected: true},
+		{name: "invalid time", content: `[{"uuid":"a","description":"a","status":"pending","due":"tomorrow"}]`, errorExpected: true},
+		{name: "not an array", content: `{"uuid":"a"}`, errorExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tw.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadTaskwarrior(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveTaskwarrior(t *testing.T) {
+	list := todo.NewTaskList([]todo.Task{
+		{
+			ID: 0, Description: "Release", Status: todo.StatusInProgress, Priority: 7, Project: "work", Tags: []string{"urgent"},
+			Due: testTime("2026-10-20T00:00:00Z"), DependsOn: []int{42}, CreatedAt: testTime("2026-10-01T09:00:00Z"),
+		},
+		{
+			ID: 42, Description: "Changelog", Status: todo.StatusDone, Priority: 4, CreatedAt: testTime("2026-10-01T09:00:00Z"),
+			CompletedAt: testTime("2026-10-03T12:00:00+02:00"),
+			Annotations: []todo.Annotation{{Time: *testTime("2026-10-02T10:00:00Z"), Text: "drafted"}},
+		},
+		{ID: 43, Description: "Blog post", Status: todo.StatusCancelled, Priority: todo.PriorityLow, CreatedAt: testTime("2026-10-01T09:00:00Z"), UpdatedAt: testTime("2026-10-04T09:00:00Z")},
+	}, 0)
+	path := filepath.Join(t.TempDir(), "tw.json")
+	if err := SaveTaskwarrior(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[\n" +
+		`{"id":0,"uuid":"6f9c1e2a-5b7d-8e43-9a1c-000000000000","description":"Release","status":"pending","entry":"20261001T090000Z","due":"20261020T000000Z","project":"work","tags":["urgent"],"priority":"H","depends":["6f9c1e2a-5b7d-8e43-9a1c-00000000002a"]},` + "\n" +
+		`{"id":0,"uuid":"6f9c1e2a-5b7d-8e43-9a1c-00000000002a","description":"Changelog","status":"completed","entry":"20261001T090000Z","end":"20261003T100000Z","priority":"M","annotations":[{"entry":"20261002T100000Z","description":"drafted"}]},` + "\n" +
+		`{"id":0,"uuid":"6f9c1e2a-5b7d-8e43-9a1c-00000000002b","description":"Blog post","status":"deleted","entry":"20261001T090000Z","modified":"20261004T090000Z","end":"20261004T090000Z","priority":"L"}` + "\n]\n"
+	if string(content) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", content, expected)
+	}
+	loaded, err := LoadTaskwarrior(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	ids := []int{}
+	for _, task := range loaded.Tasks {
+		ids = append(ids, task.ID)
+	}
+	if !slices.Equal(ids, []int{0, 42, 43}) || !slices.Equal(loaded.Tasks[0].DependsOn, []int{42}) || loaded.NextID != 44 {
+		t.Errorf("Test failed: unexpected tasks after loading them back %+v", loaded)
+	}
+}
+
+func TestTaskwarriorUUIDs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tw.json")
+	content := "[\n" +
+		`{"id":1,"description":"Call vendor","status":"pending","entry":"20261001T090000Z","uuid":"1f2d3c4b-0000-4000-8000-000000000001","depends":["1f2d3c4b-0000-4000-8000-000000000002"]},` + "\n" +
+		`{"id":2,"description":"Get a quote","status":"pending","entry":"20261001T090000Z","uuid":"1f2d3c4b-0000-4000-8000-000000000002"}` + "\n]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := OpenTaskwarrior(path).Load()
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+
+	// exporting gives the imported tasks their UUIDs back, a new task a derived one
+	out := filepath.Join(dir, "out.json")
+	tasks := append(slices.Clone(loaded.Tasks), todo.Task{ID: 2, Description: "Sign", Status: todo.StatusTodo, CreatedAt: testTime("2026-10-02T09:00:00Z")})
+	if err := SaveTaskwarrior(out, todo.NewTaskList(tasks, 0)); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	saved, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[\n" +
+		`{"id":0,"uuid":"1f2d3c4b-0000-4000-8000-000000000001","description":"Call vendor","status":"pending","entry":"20261001T090000Z","depends":["1f2d3c4b-0000-4000-8000-000000000002"]},` + "\n" +
+		`{"id":1,"uuid":"1f2d3c4b-0000-4000-8000-000000000002","description":"Get a quote","status":"pending","entry":"20261001T090000Z"},` + "\n" +
+		`{"id":2,"uuid":"6f9c1e2a-5b7d-8e43-9a1c-000000000002","description":"Sign","status":"pending","entry":"20261002T090000Z"}` + "\n]\n"
+	if string(saved) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", saved, expected)
+	}
+
+	// undoing a load over a native task 0 takes the UUID away with the loaded task
+	native := todo.NewTaskList([]todo.Task{{ID: 0, Description: "native zero", Status: todo.StatusTodo}}, 0)
+	journal := todo.Journal{}.Record(todo.Operation{Command: "load", Changes: todo.Diff(native, loaded)})
+	restored, _, _, err := journal.Undo(loaded, 1)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if err := SaveTaskwarrior(out, restored); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if saved, err = os.ReadFile(out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), `"uuid":"6f9c1e2a-5b7d-8e43-9a1c-000000000000","description":"native zero"`) {
+		t.Errorf("Test failed: native task not exported with its derived UUID\n%s", saved)
+	}
+
+	// loading the tasks again in another order keeps their ids
+	reordered := "[\n" +
+		`{"description":"Get a quote","status":"pending","uuid":"1f2d3c4b-0000-4000-8000-000000000002"},` + "\n" +
+		`{"description":"New in Taskwarrior","status":"pending","uuid":"1f2d3c4b-0000-4000-8000-000000000003"},` + "\n" +
+		`{"description":"Call vendor","status":"pending","uuid":"1f2d3c4b-0000-4000-8000-000000000001"}` + "\n]\n"
+	if err := os.WriteFile(path, []byte(reordered), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := OpenTaskwarrior(path)
+	for _, task := range loaded.Tasks {
+		source.Known[task.UUID] = task.ID
+	}
+	reloaded, err := source.Load()
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	ids := []int{}
+	for _, task := range reloaded.Tasks {
+		ids = append(ids, task.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 0}) || reloaded.Tasks[2].Description != "Call vendor" {
+		t.Errorf("Test failed: unexpected tasks after loading them again %+v", reloaded.Tasks)
+	}
+}
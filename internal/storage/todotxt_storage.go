@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// The todo.txt key:value extensions understood by the codec; other key:value
+// words are kept in the description.
+const (
+	todoTxtID        string = "id"
+	todoTxtParent    string = "parent"
+	todoTxtDepends   string = "dep"
+	todoTxtDue       string = "due"
+	todoTxtScheduled string = "t"
+	todoTxtRecur     string = "rec"
+	todoTxtEstimate  string = "estimate"
+	todoTxtStatus    string = "status"
+	todoTxtPriority  string = "pri"
+	todoTxtArchived  string = "archived"
+)
+
+// In todo.txt +word is a project and @word a context, stored as a tag.
+const (
+	todoTxtProjectPrefix string = "+"
+	todoTxtContextPrefix string = "@"
+)
+
+var (
+	todoTxtPriorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	// rec:1w repeats a week after completion, rec:+1w a week after the due date
+	todoTxtRecurPattern = regexp.MustCompile(`^(\+?)(\d+)([dwmy])$`)
+)
+
+var todoTxtFrequencies = map[string]string{"d": "DAILY", "w": "WEEKLY", "m": "MONTHLY", "y": "YEARLY"}
+
+// LoadTodoTxt reads a todo.txt file, one task per line. Lines without an id:
+// extension get the ids following the largest one in the file. Unlike a task
+// store, a missing file is an error.
+func LoadTodoTxt(path string) (todo.TaskList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the todo.txt storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read todo.txt storage: %w", err)
+	}
+	defer file.Close()
+
+	tasks := []todo.Task{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		task, err := unmarshalTodoTxtTask(scanner.Text())
+		if err != nil {
+			logging.Logger.Error("Error parsing a todo.txt line", "error", err.Error(), "line", number, "file", path)
+			return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid todo.txt line %d: %w", number, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		logging.Logger.Error("Error reading the todo.txt storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to read todo.txt storage: %w", err)
+	}
+
+	numbered := slices.DeleteFunc(slices.Clone(tasks), func(task todo.Task) bool { return task.ID < 0 })
+	nextID := todo.NewTaskList(numbered, 0).NextID
+	list := todo.TaskList{Tasks: []todo.Task{}}
+	for _, task := range tasks {
+		if task.ID < 0 {
+			task.ID = nextID
+			nextID++
+		}
+		if task.ArchivedAt != nil {
+			list.Archived = append(list.Archived, task)
+		} else {
+			list.Tasks = append(list.Tasks, task)
+		}
+	}
+	list = list.Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid todo.txt storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid todo.txt storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from todo.txt", "amount", len(list.Tasks), "archived", len(list.Archived))
+	return list, nil
+}
+
+// SaveTodoTxt writes one line per task. todo.txt has no place for the next
+// id, annotations, notes or tracked time, so those are not saved.
+func SaveTodoTxt(path string, list todo.TaskList) error {
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+			if _, err := fmt.Fprintln(w, marshalTodoTxtTask(task)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Logger.Error("Error saving the todo.txt storage", "error", err.Error(), "path", path)
+		return fmt.Errorf("failed to save todo.txt storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to todo.txt", "amount", len(list.Tasks), "archived", len(list.Archived))
+	return nil
+}
+
+// marshalTodoTxtTask renders a task as
+// "x <completed> (A) <created> description +project @tag key:value...".
+// Closed tasks are marked with x and keep their priority in pri:.
+func marshalTodoTxtTask(task todo.Task) string {
+	words := []string{}
+	closed := task.Status.Closed()
+	if closed {
+		words = append(words, "x")
+		if task.CompletedAt != nil {
+			words = append(words, task.CompletedAt.Local().Format(todo.DateLayout))
+		}
+	}
+	if task.Priority != todo.PriorityNone && !closed {
+		words = append(words, "("+formatTodoTxtPriority(task.Priority)+")")
+	}
+	// a lone date after x is the completion date
+	if task.CreatedAt != nil && (!closed || task.CompletedAt != nil) {
+		words = append(words, task.CreatedAt.Local().Format(todo.DateLayout))
+	}
+	words = append(words, task.Description)
+	if task.Project != "" {
+		words = append(words, todoTxtProjectPrefix+task.Project)
+	}
+	for _, tag := range task.Tags {
+		words = append(words, todoTxtContextPrefix+tag)
+	}
+	extension := func(key string, value string) {
+		words = append(words, key+":"+value)
+	}
+	if task.Due != nil {
+		extension(todoTxtDue, task.Due.Format(todo.DateLayout))
+	}
+	if task.Scheduled != nil {
+		extension(todoTxtScheduled, task.Scheduled.Format(todo.DateLayout))
+	}
+	if task.Recurrence != nil {
+		extension(todoTxtRecur, task.Recurrence.String())
+	}
+	if task.Estimate != nil {
+		extension(todoTxtEstimate, task.Estimate.String())
+	}
+	if task.Status != todo.StatusDone && (closed || task.Status != todo.ActiveWorkflow.Initial()) {
+		extension(todoTxtStatus, string(task.Status))
+	}
+	if task.Priority != todo.PriorityNone && closed {
+		extension(todoTxtPriority, formatTodoTxtPriority(task.Priority))
+	}
+	if task.Parent != nil {
+		extension(todoTxtParent, strconv.Itoa(*task.Parent))
+	}
+	if len(task.DependsOn) > 0 {
+		extension(todoTxtDepends, strings.ReplaceAll(formatCSVIDs(task.DependsOn), csvTagSeparator, ","))
+	}
+	if task.ArchivedAt != nil {
+		extension(todoTxtArchived, task.ArchivedAt.Local().Format(todo.DateLayout))
+	}
+	extension(todoTxtID, strconv.Itoa(task.ID))
+	return strings.Join(words, " ")
+}
+
+// unmarshalTodoTxtTask parses a line written by marshalTodoTxtTask or by hand;
+// the id is -1 when the line has no id: extension.
+func unmarshalTodoTxtTask(line string) (todo.Task, error) {
+	task := todo.Task{ID: -1, Status: todo.ActiveWorkflow.Initial()}
+	words := strings.Fields(line)
+	if len(words) > 0 && words[0] == "x" {
+		task.Status = todo.StatusDone
+		words = words[1:]
+		if len(words) > 0 && todoTxtDatePattern.MatchString(words[0]) {
+			completed, err := parseTodoTxtDate(words[0])
+			if err != nil {
+				return task, err
+			}
+			task.CompletedAt, words = completed, words[1:]
+		}
+	}
+	if len(words) > 0 {
+		if match := todoTxtPriorityPattern.FindStringSubmatch(words[0]); match != nil {
+			task.Priority, words = parseTodoTxtPriority(match[1]), words[1:]
+		}
+	}
+	if len(words) > 0 && todoTxtDatePattern.MatchString(words[0]) {
+		created, err := parseTodoTxtDate(words[0])
+		if err != nil {
+			return task, err
+		}
+		task.CreatedAt, words = created, words[1:]
+	}
+
+	description := []string{}
+	for _, word := range words {
+		switch {
+		case strings.HasPrefix(word, todoTxtProjectPrefix) && len(word) > 1 && todo.ValidateProject(word[1:]) == nil:
+			// todo.txt allows several projects, the first one is kept as the project
+			if task.Project == "" {
+				task.Project = word[1:]
+			} else {
+				task.Tags = append(task.Tags, word[1:])
+			}
+		case strings.HasPrefix(word, todoTxtContextPrefix) && todo.ValidateTag(word[1:]) == nil:
+			task.Tags = append(task.Tags, word[1:])
+		default:
+			key, value, ok := strings.Cut(word, ":")
+			if !ok || value == "" {
+				description = append(description, word)
+				continue
+			}
+			known, err := setTodoTxtExtension(&task, key, value)
+			if err != nil {
+				return task, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if !known {
+				description = append(description, word)
+			}
+		}
+	}
+	if task.Status != todo.StatusDone {
+		task.CompletedAt = nil
+	}
+	task.Description = strings.Join(description, " ")
+	if task.Description == "" {
+		return task, errors.New("the task has no description")
+	}
+	tags, err := todo.NormalizeTags(task.Tags)
+	if err != nil {
+		return task, err
+	}
+	task.Tags = nil
+	if len(tags) > 0 {
+		task.Tags = tags
+	}
+	return task, nil
+}
+
+// setTodoTxtExtension stores a key:value extension in task, reporting false
+// for keys the codec does not know.
+func setTodoTxtExtension(task *todo.Task, key string, value string) (bool, error) {
+	var err error
+	switch key {
+	case todoTxtID:
+		task.ID, err = strconv.Atoi(value)
+	case todoTxtParent:
+		task.Parent, err = parseCSVID(value)
+	case todoTxtDepends:
+		task.DependsOn, err = parseCSVIDs(strings.ReplaceAll(value, ",", csvTagSeparator))
+	case todoTxtDue:
+		task.Due, err = parseTodoTxtDate(value)
+	case todoTxtScheduled:
+		task.Scheduled, err = parseTodoTxtDate(value)
+	case todoTxtArchived:
+		task.ArchivedAt, err = parseTodoTxtDate(value)
+	case todoTxtRecur:
+		task.Recurrence, err = parseTodoTxtRecurrence(value)
+	case todoTxtEstimate:
+		task.Estimate, err = parseCSVEstimate(value)
+	case todoTxtStatus:
+		task.Status = todo.Status(value)
+	case todoTxtPriority:
+		if !todoTxtPriorityPattern.MatchString("(" + value + ")") {
+			return true, fmt.Errorf("expected a letter from A to Z, got %q", value)
+		}
+		task.Priority = parseTodoTxtPriority(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// todo.txt priorities run from A (highest) to Z; A to I map onto 9 to 1 and
+// the letters after I onto 1.
+func formatTodoTxtPriority(priority todo.Priority) string {
+	levels := int(todo.PriorityHigh)
+	return string(rune('A' + levels - priority.Rank(levels)))
+}
+
+func parseTodoTxtPriority(letter string) todo.Priority {
+	return max(todo.PriorityHigh-todo.Priority(letter[0]-'A'), todo.PriorityLow)
+}
+
+func parseTodoTxtDate(value string) (*time.Time, error) {
+	date, err := todo.ParseDate(value, todo.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// parseTodoTxtRecurrence accepts the RRULE form written by the codec as well
+// as the usual todo.txt rules such as 1w or +2d.
+func parseTodoTxtRecurrence(value string) (*todo.Recurrence, error) {
+	if match := todoTxtRecurPattern.FindStringSubmatch(value); match != nil {
+		value = fmt.Sprintf("FREQ=%s;INTERVAL=%s", todoTxtFrequencies[match[3]], match[2])
+		if match[1] == "" {
+			value += ";FROM=COMPLETION"
+		}
+	}
+	return parseCSVRecurrence(value)
+}
+
+func init() {
+	RegisterExchange("todotxt", func(path string) (Store, error) {
+		return NewFileStore(path, LoadTodoTxt, SaveTodoTxt), nil
+	}, ".txt")
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestLoadTodoTxt(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "hand-written lines",
+			content: "x 2026-10-01 2026-09-28 Call vendor +proj @phone due:2026-10-20\n" +
+				"\n" +
+				"(A) 2026-10-02 Review the spec +work.backend @office @Office see http://example.com\n" +
+				"(C) Water plants rec:+1w due:2026-10-25 id:7\n" +
+				"(Z) Someday t:2026-11-01 estimate:2h\n",
+			expected: []todo.Task{
+				{
+					ID: 8, Description: "Call vendor", Status: todo.StatusDone, Project: "proj", Tags: []string{"phone"},
+					Due: testDate(2026, 10, 20), CreatedAt: testDate(2026, 9, 28), CompletedAt: testDate(2026, 10, 1),
+				},
+				{
+					ID: 9, Description: "Review the spec see http://example.com", Status: todo.StatusTodo, Priority: todo.PriorityHigh,
+					Project: "work.backend", Tags: []string{"office", "Office"}, CreatedAt: testDate(2026, 10, 2),
+				},
+				{
+					ID: 7, Description: "Water plants", Status: todo.StatusTodo, Priority: 7, Due: testDate(2026, 10, 25),
+					Recurrence: testRecurrence("FREQ=WEEKLY"),
+				},
+				{
+					ID: 10, Description: "Someday", Status: todo.StatusTodo, Priority: todo.PriorityLow, Scheduled: testDate(2026, 11, 1),
+					Estimate: &todo.Estimate{Amount: 2, Unit: todo.Hours},
+				},
+			},
+		},
+		{name: "no description", content: "(A) +proj @ctx due:2026-10-20\n", errorExpected: true},
+		{name: "invalid date", content: "Call vendor due:someday\n", errorExpected: true},
+		{name: "missing parent", content: "Call vendor parent:4\n", errorExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "todo.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadTodoTxt(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTodoTxtExtensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	content := "x Retire the old API status:cancelled pri:B parent:1 id:2\nOld API id:1\n" +
+		"Legacy id:0 archived:2026-10-01\nLegacy docs dep:0 id:3 archived:2026-10-01\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadTodoTxt(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if len(list.Tasks) != 2 || len(list.Archived) != 2 || list.NextID != 4 {
+		t.Fatalf("Test failed: unexpected list %+v", list)
+	}
+	task := list.Tasks[0]
+	if task.Status != todo.StatusCancelled || task.Priority != 8 || !sameID(task.Parent, testID(1)) {
+		t.Errorf("Test failed: unexpected task %+v", task)
+	}
+	if !slices.Equal(list.Archived[1].DependsOn, []int{0}) || !sameTime(list.Archived[1].ArchivedAt, testDate(2026, 10, 1)) {
+		t.Errorf("Test failed: unexpected archived task %+v", list.Archived[1])
+	}
+}
+
+func TestSaveTodoTxt(t *testing.T) {
+	parent := 0
+	list := todo.NewTaskList([]todo.Task{
+		{
+			ID: 0, Description: "Release", Status: todo.StatusInProgress, Priority: todo.PriorityHigh, Project: "work",
+			Tags: []string{"urgent"}, Due: testDate(2026, 10, 20), CreatedAt: testDate(2026, 10, 1),
+			Estimate: &todo.Estimate{Amount: 5, Unit: todo.Points},
+		},
+		{
+			ID: 1, Description: "Changelog", Status: todo.StatusDone, Priority: todo.PriorityLow, Parent: &parent,
+			CreatedAt: testDate(2026, 10, 2), CompletedAt: testDate(2026, 10, 3),
+		},
+		{
+			ID: 4, Description: "Standup", Status: todo.StatusTodo, DependsOn: []int{0, 1},
+			Scheduled: testDate(2026, 10, 19), Recurrence: testRecurrence("FREQ=DAILY;INTERVAL=2"),
+		},
+	}, 0)
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	if err := SaveTodoTxt(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "(A) 2026-10-01 Release +work @urgent due:2026-10-20 estimate:5pt status:in-progress id:0\n" +
+		"x 2026-10-03 2026-10-02 Changelog pri:I parent:0 id:1\n" +
+		"Standup t:2026-10-19 rec:FREQ=DAILY;INTERVAL=2 dep:0,1 id:4\n"
+	if string(content) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", content, expected)
+	}
+	loaded, err := LoadTodoTxt(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if !slices.EqualFunc(loaded.Tasks, list.Tasks, sameTask) {
+		t.Errorf("Test failed: got %+v, expected %+v", loaded.Tasks, list.Tasks)
+	}
+}
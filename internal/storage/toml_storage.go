@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func LoadTOML(path string) (todo.TaskList, error) {
+	list := todo.TaskList{Tasks: []todo.Task{}}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logging.Logger.Debug(fmt.Sprintf("The toml storage file is missing. Creating %s", path))
+			SaveTOML(path, list)
+		} else {
+			logging.Logger.Error("File stats request failed unexpectively", "error", err.Error(), "file", path)
+			return list, fmt.Errorf("failed to access toml storage: %w", err)
+		}
+	}
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the toml storage file", "error", err.Error(), "file", path)
+		return list, fmt.Errorf("failed to read toml storage: %w", err)
+	}
+	metadata, err := toml.Decode(string(fileBytes), &list)
+	if err == nil && len(metadata.Undecoded()) > 0 {
+		keys := make([]string, len(metadata.Undecoded()))
+		for i, key := range metadata.Undecoded() {
+			keys[i] = key.String()
+		}
+		err = fmt.Errorf("unknown field(s) %s", strings.Join(keys, ", "))
+	}
+	if err != nil {
+		logging.Logger.Error("Error unmarshalling the toml storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to parse toml: %w", err)
+	}
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	list = defaultStatuses(list).Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid toml storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid toml storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from toml", "amount", len(list.Tasks), "next_id", list.NextID)
+	return list, nil
+}
+
+// SaveTOML writes the fields in declaration order, except that TOML puts the
+// tables of annotations and intervals after the plain fields of a task.
+// Comments in the file being replaced are not kept.
+func SaveTOML(path string, list todo.TaskList) error {
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		encoder := toml.NewEncoder(w)
+		encoder.Indent = ""
+		return encoder.Encode(list)
+	}); err != nil {
+		logging.Logger.Error("Failed writing to file", "error", err.Error(), "tasks", list.Tasks, "path", path)
+		return fmt.Errorf("failed to write to toml storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to toml", "amount", len(list.Tasks), "next_id", list.NextID)
+	return nil
+}
+
+func init() {
+	Register("toml", func(path string) (Store, error) {
+		return NewFileStore(path, LoadTOML, SaveTOML), nil
+	}, ".toml")
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+func TestLoadTOML(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "hand-written",
+			content: "# my tasks\n[[Tasks]]\nID = 4\nDescription = \"Call vendor\" # by friday\nDue = 2026-10-20T00:00:00Z\n" +
+				"Estimate = \"2h\"\n\n[[Tasks]]\nID = 1\nDescription = \"Pay rent\"\nStatus = \"done\"\n",
+			expected: []todo.Task{
+				{ID: 4, Description: "Call vendor", Status: todo.StatusTodo, Due: testTime("2026-10-20T00:00:00Z"), Estimate: &todo.Estimate{Amount: 2, Unit: todo.Hours}},
+				{ID: 1, Description: "Pay rent", Status: todo.StatusDone},
+			},
+		},
+		{name: "empty file", content: "", expected: []todo.Task{}},
+		{name: "unknown field", content: "[[Tasks]]\nID = 1\nDescription = \"a\"\nDone = true\n", errorExpected: true},
+		{name: "invalid estimate", content: "[[Tasks]]\nID = 1\nDescription = \"a\"\nEstimate = \"soon\"\n", errorExpected: true},
+		{name: "invalid syntax", content: "[[Tasks]\nID = 1\n", errorExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadTOML(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.toml")
+	list := todo.NewTaskList(richTasks(), 7)
+	if err := SaveTOML(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	loaded, err := LoadTOML(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if !slices.EqualFunc(loaded.Tasks, list.Tasks, sameTask) || loaded.NextID != 7 {
+		t.Errorf("Test failed: got %+v, expected %+v", loaded, list)
+	}
+
+	list = todo.NewTaskList([]todo.Task{
+		{ID: 0, Description: "Task A", Status: todo.StatusTodo, Tags: []string{"x"}, Priority: 3},
+		{
+			ID: 1, Description: "Task B", Status: todo.StatusDone, Parent: testID(0),
+			Annotations: []todo.Annotation{{Time: *testTime("2026-10-02T10:00:00Z"), Text: "done"}},
+		},
+	}, 0)
+	if err := SaveTOML(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "NextID = 2\n\n[[Tasks]]\nID = 0\nDescription = \"Task A\"\nStatus = \"todo\"\nPriority = 3\nTags = [\"x\"]\n\n" +
+		"[[Tasks]]\nID = 1\nDescription = \"Task B\"\nStatus = \"done\"\nParent = 0\n\n" +
+		"[[Tasks.Annotations]]\nTime = 2026-10-02T10:00:00Z\nText = \"done\"\n"
+	if string(saved) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", saved, expected)
+	}
+}
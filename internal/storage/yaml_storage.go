@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+const yamlIndent = 2
+
+func LoadYAML(path string) (todo.TaskList, error) {
+	list := todo.TaskList{Tasks: []todo.Task{}}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logging.Logger.Debug(fmt.Sprintf("The yaml storage file is missing. Creating %s", path))
+			SaveYAML(path, list)
+		} else {
+			logging.Logger.Error("File stats request failed unexpectively", "error", err.Error(), "file", path)
+			return list, fmt.Errorf("failed to access yaml storage: %w", err)
+		}
+	}
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		logging.Logger.Error("Error reading the yaml storage file", "error", err.Error(), "file", path)
+		return list, fmt.Errorf("failed to read yaml storage: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(fileBytes))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&list); err != nil && !errors.Is(err, io.EOF) {
+		logging.Logger.Error("Error unmarshalling the yaml storage file", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("failed to parse yaml: %w", err)
+	}
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	list = defaultStatuses(list).Normalize()
+	if err := todo.Validate(list); err != nil {
+		logging.Logger.Error("Invalid yaml storage contents", "error", err.Error(), "file", path)
+		return todo.TaskList{Tasks: []todo.Task{}}, fmt.Errorf("invalid yaml storage: %w", err)
+	}
+	logging.Logger.Debug("Loaded tasks from yaml", "amount", len(list.Tasks), "next_id", list.NextID)
+	return list, nil
+}
+
+// SaveYAML writes the fields in declaration order. Comments in the file being
+// replaced stay on the keys and tasks they were written next to.
+func SaveYAML(path string, list todo.TaskList) error {
+	if list.Tasks == nil {
+		list.Tasks = []todo.Task{}
+	}
+	var value yaml.Node
+	if err := value.Encode(list); err != nil {
+		logging.Logger.Error("Error marshalling tasks to yaml", "error", err.Error(), "tasks", list.Tasks)
+		return fmt.Errorf("failed to dump yaml: %w", err)
+	}
+	document := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&value}}
+	if previousBytes, err := os.ReadFile(path); err == nil {
+		var previous yaml.Node
+		if yaml.Unmarshal(previousBytes, &previous) == nil {
+			carryYAMLComments(&previous, document)
+		}
+	}
+	if err := writeFileAtomic(path, func(w io.Writer) error {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(yamlIndent)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}); err != nil {
+		logging.Logger.Error("Failed writing to file", "error", err.Error(), "tasks", list.Tasks, "path", path)
+		return fmt.Errorf("failed to write to yaml storage: %w", err)
+	}
+	logging.Logger.Debug("Save tasks to yaml", "amount", len(list.Tasks), "next_id", list.NextID)
+	return nil
+}
+
+// carryYAMLComments copies the comments of from onto the matching nodes of to.
+// Mapping entries match by key and the items of a sequence by their ID, or by
+// position when they have none.
+func carryYAMLComments(from *yaml.Node, to *yaml.Node) {
+	if from.Kind != to.Kind {
+		return
+	}
+	to.HeadComment, to.LineComment, to.FootComment = from.HeadComment, from.LineComment, from.FootComment
+	switch to.Kind {
+	case yaml.DocumentNode:
+		for i := 0; i < len(from.Content) && i < len(to.Content); i++ {
+			carryYAMLComments(from.Content[i], to.Content[i])
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(to.Content); i += 2 {
+			if j := yamlKey(from, to.Content[i].Value); j >= 0 {
+				carryYAMLComments(from.Content[j], to.Content[i])
+				carryYAMLComments(from.Content[j+1], to.Content[i+1])
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range to.Content {
+			if id := yamlKey(item, "ID"); id >= 0 {
+				for _, previous := range from.Content {
+					if j := yamlKey(previous, "ID"); j >= 0 && previous.Content[j+1].Value == item.Content[id+1].Value {
+						carryYAMLComments(previous, item)
+					}
+				}
+			} else if i < len(from.Content) {
+				carryYAMLComments(from.Content[i], item)
+			}
+		}
+	}
+}
+
+// yamlKey returns the index of key in a mapping node, -1 when it is missing.
+func yamlKey(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func init() {
+	Register("yaml", func(path string) (Store, error) {
+		return NewFileStore(path, LoadYAML, SaveYAML), nil
+	}, ".yaml", ".yml")
+}
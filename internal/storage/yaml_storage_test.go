@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/vladiakimenko/go_project_planner/internal/todo"
+)
+
+// richTasks sets every field, for the formats that store all of them.
+func richTasks() []todo.Task {
+	return []todo.Task{
+		{
+			ID: 0, Description: "Release: v2 \"final\"", Status: todo.StatusInProgress, Due: testDate(2026, 10, 20),
+			Scheduled: testDate(2026, 10, 18), Priority: todo.PriorityHigh, Estimate: &todo.Estimate{Amount: 1.5, Unit: todo.Hours},
+			Project: "work.backend", Tags: []string{"urgent", "db"}, Recurrence: testRecurrence("FREQ=WEEKLY;BYDAY=MO,FR"),
+			Annotations: []todo.Annotation{{Time: *testTime("2026-10-02T10:00:00Z"), Text: "asked for a review"}},
+			Notes:       "first line\nsecond line",
+			Intervals: []todo.Interval{
+				{Start: *testTime("2026-10-02T09:00:00Z"), End: testTime("2026-10-02T10:00:00Z")},
+				{Start: *testTime("2026-10-03T09:00:00Z")},
+			},
+			CreatedAt: testTime("2026-10-01T09:30:00.123456789+02:00"), UpdatedAt: testTime("2026-10-03T09:00:00Z"),
+			UUID: "1f2d3c4b-0000-4000-8000-000000000001",
+		},
+		{
+			ID: 2, Description: "yes", Status: todo.StatusDone, Parent: testID(0), DependsOn: []int{3},
+			CompletedAt: testTime("2026-10-02T10:00:00Z"),
+		},
+		{ID: 3, Description: "123", Status: todo.StatusTodo},
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      []todo.Task
+		errorExpected bool
+	}{
+		{
+			name: "hand-written",
+			content: "# my tasks\nTasks:\n  - ID: 4\n    Description: Call vendor # by friday\n" +
+				"    Due: 2026-10-20T00:00:00Z\n    Estimate: 2h\n  - ID: 1\n    Description: Pay rent\n    Status: done\n",
+			expected: []todo.Task{
+				{ID: 4, Description: "Call vendor", Status: todo.StatusTodo, Due: testTime("2026-10-20T00:00:00Z"), Estimate: &todo.Estimate{Amount: 2, Unit: todo.Hours}},
+				{ID: 1, Description: "Pay rent", Status: todo.StatusDone},
+			},
+		},
+		{name: "empty file", content: "", expected: []todo.Task{}},
+		{name: "unknown field", content: "Tasks:\n  - ID: 1\n    Description: a\n    Done: true\n", errorExpected: true},
+		{name: "invalid estimate", content: "Tasks:\n  - ID: 1\n    Description: a\n    Estimate: soon\n", errorExpected: true},
+		{name: "duplicate ids", content: "Tasks:\n  - ID: 1\n    Description: a\n  - ID: 1\n    Description: b\n", errorExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := LoadYAML(path)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if tt.expected != nil && !slices.EqualFunc(list.Tasks, tt.expected, sameTask) {
+				t.Errorf("Test failed: got %+v, expected %+v", list.Tasks, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	list := todo.NewTaskList(richTasks(), 7)
+	if err := SaveYAML(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	loaded, err := LoadYAML(path)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if !slices.EqualFunc(loaded.Tasks, list.Tasks, sameTask) || loaded.NextID != 7 {
+		t.Errorf("Test failed: got %+v, expected %+v", loaded, list)
+	}
+
+	content := "# the team's tasks\nNextID: 3\nTasks:\n  # first\n  - ID: 0\n    Description: Task A # short\n    Status: todo\n" +
+		"  - ID: 1\n    Description: Task B\n    Status: todo\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list = todo.NewTaskList([]todo.Task{
+		{ID: 1, Description: "Task B", Status: todo.StatusDone},
+		{ID: 0, Description: "Task A", Status: todo.StatusTodo, Tags: []string{"x"}},
+	}, 3)
+	if err := SaveYAML(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# the team's tasks\nNextID: 3\nTasks:\n  - ID: 1\n    Description: Task B\n    Status: done\n" +
+		"  # first\n  - ID: 0\n    Description: Task A # short\n    Status: todo\n    Tags:\n      - x\n"
+	if string(saved) != expected {
+		t.Errorf("Test failed: got\n%s\nexpected\n%s", saved, expected)
+	}
+	if err := SaveYAML(path, list); err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if again, _ := os.ReadFile(path); !reflect.DeepEqual(again, saved) {
+		t.Errorf("Test failed: saving again changed the file to\n%s", again)
+	}
+}
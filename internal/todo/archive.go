@@ -0,0 +1,93 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ArchiveMany moves the requested tasks, along with their subtasks, out of
+// the active list into the archive. Archiving cuts the links between the
+// archived and the active tasks: parents left behind are forgotten and
+// dependencies across the archive boundary are dropped on both sides.
+// Running timers are stopped.
+func ArchiveMany(list TaskList, ids []int) (TaskList, BulkResult) {
+	now := Now()
+	var result BulkResult
+	list.Tasks, list.Archived, result = moveTasks(list.Tasks, list.Archived, ids, &now)
+	logBulkResult("archive", result)
+	return list, result
+}
+
+// RestoreMany brings archived tasks, along with their archived subtasks,
+// back to the active list, cutting their links to the tasks that stay
+// archived.
+func RestoreMany(list TaskList, ids []int) (TaskList, BulkResult) {
+	var result BulkResult
+	list.Archived, list.Tasks, result = moveTasks(list.Archived, list.Tasks, ids, nil)
+	logBulkResult("restore", result)
+	return list, result
+}
+
+// Purge permanently removes the tasks archived before the given time and
+// returns their ids. Archived subtasks of purged tasks that are kept become
+// top-level tasks.
+func Purge(list TaskList, before time.Time) (TaskList, []int) {
+	ids := []int{}
+	for _, task := range list.Archived {
+		if task.ArchivedAt.Before(before) {
+			ids = append(ids, task.ID)
+		}
+	}
+	var result BulkResult
+	list.Archived, result = removeTasks(list.Archived, ids, ChildrenOrphan)
+	logBulkResult("purge", result)
+	return list, result.Affected
+}
+
+// moveTasks takes the requested tasks and their subtrees from one side of the
+// archive to the other, stamping them with archivedAt.
+func moveTasks(from []Task, to []Task, ids []int, archivedAt *time.Time) ([]Task, []Task, BulkResult) {
+	remaining, result := removeTasks(from, ids, ChildrenCascade)
+	moving := make(map[int]bool, len(result.Affected))
+	for _, id := range result.Affected {
+		moving[id] = true
+	}
+	now := Now()
+	to = slices.Clone(to)
+	for _, task := range from {
+		if !moving[task.ID] {
+			continue
+		}
+		if task.Parent != nil && !moving[*task.Parent] {
+			task.Parent = nil
+			task.UpdatedAt = &now
+		}
+		if slices.ContainsFunc(task.DependsOn, func(id int) bool { return !moving[id] }) {
+			task.DependsOn = normalizeIDs(slices.DeleteFunc(slices.Clone(task.DependsOn), func(id int) bool { return !moving[id] }))
+			task.UpdatedAt = &now
+		}
+		task.ArchivedAt = archivedAt
+		task.Intervals = stopTimer(task.Intervals, now)
+		to = append(to, task)
+	}
+	slices.SortStableFunc(to, func(a, b Task) int { return cmp.Compare(a.ID, b.ID) })
+	return remaining, to, result
+}
+
+// validateArchive checks that exactly the archived tasks carry an archive
+// time.
+func validateArchive(list TaskList) error {
+	for _, task := range list.Tasks {
+		if task.ArchivedAt != nil {
+			return fmt.Errorf("active task id=%d has an archive time", task.ID)
+		}
+	}
+	for _, task := range list.Archived {
+		if task.ArchivedAt == nil {
+			return fmt.Errorf("archived task id=%d has no archive time", task.ID)
+		}
+	}
+	return nil
+}
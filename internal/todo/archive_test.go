@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return clock }
+	defer func() { Now = time.Now }()
+	parent := 0
+	tasks := []Task{
+		{ID: 0, Description: "Release", Status: StatusTodo},
+		{ID: 1, Description: "Changelog", Status: StatusDone, Parent: &parent},
+		{ID: 2, Description: "Announce", Status: StatusTodo, DependsOn: []int{1, 3}},
+		{ID: 3, Description: "Blog post", Status: StatusInProgress, Intervals: []Interval{{Start: clock.Add(-time.Hour)}}},
+		{ID: 4, Description: "Groceries", Status: StatusTodo},
+	}
+
+	list, result := ArchiveMany(NewTaskList(tasks, 0), []int{0, 3, 9})
+	if err := Validate(list); err != nil {
+		t.Fatalf("Test failed: archiving left an invalid list: %v", err)
+	}
+	if !slices.Equal(result.Affected, []int{0, 1, 3}) || !slices.Equal(result.Missing, []int{9}) {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+	if ids := taskIDs(list.Tasks); !slices.Equal(ids, []int{2, 4}) {
+		t.Errorf("Test failed: active tasks %v, expected [2 4]", ids)
+	}
+	if ids := taskIDs(list.Archived); !slices.Equal(ids, []int{0, 1, 3}) {
+		t.Errorf("Test failed: archived tasks %v, expected [0 1 3]", ids)
+	}
+	if len(list.Tasks[0].DependsOn) != 0 {
+		t.Errorf("Test failed: dependencies on archived tasks kept: %v", list.Tasks[0].DependsOn)
+	}
+	if list.Archived[1].Parent == nil || list.Archived[2].ArchivedAt == nil || list.Archived[2].timerRunning() {
+		t.Errorf("Test failed: unexpected archived tasks %+v", list.Archived)
+	}
+
+	list, result = RestoreMany(list, []int{1, 4})
+	if err := Validate(list); err != nil {
+		t.Fatalf("Test failed: restoring left an invalid list: %v", err)
+	}
+	if !slices.Equal(result.Affected, []int{1}) || !slices.Equal(result.Missing, []int{4}) {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+	if ids := taskIDs(list.Tasks); !slices.Equal(ids, []int{1, 2, 4}) {
+		t.Errorf("Test failed: active tasks %v, expected [1 2 4]", ids)
+	}
+	if restored := list.Tasks[0]; restored.Parent != nil || restored.ArchivedAt != nil {
+		t.Errorf("Test failed: restored task still linked to the archive: %+v", restored)
+	}
+
+	list = Add(list, Task{Description: "Next release"})
+	if list.Tasks[len(list.Tasks)-1].ID != 5 {
+		t.Errorf("Test failed: reused an archived id: %+v", list.Tasks)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 12, 0, 0, 0, time.UTC)
+		return &date
+	}
+	parent := 0
+	list := NewTaskList(nil, 0)
+	list.Archived = []Task{
+		{ID: 0, Description: "Old", Status: StatusDone, ArchivedAt: day(1)},
+		{ID: 1, Description: "Child", Status: StatusDone, Parent: &parent, ArchivedAt: day(10)},
+		{ID: 2, Description: "Recent", Status: StatusDone, DependsOn: []int{0}, ArchivedAt: day(18)},
+	}
+	list = list.Normalize()
+	list, purged := Purge(list, *day(10))
+	if !slices.Equal(purged, []int{0}) {
+		t.Errorf("Test failed: purged %v, expected [0]", purged)
+	}
+	if err := Validate(list); err != nil {
+		t.Errorf("Test failed: purging left an invalid list: %v", err)
+	}
+	if ids := taskIDs(list.Archived); !slices.Equal(ids, []int{1, 2}) {
+		t.Errorf("Test failed: archived tasks %v, expected [1 2]", ids)
+	}
+}
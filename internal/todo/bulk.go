@@ -0,0 +1,183 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+const maxIDRange int = 10000
+
+// BulkResult reports what a multi-task operation did: Affected holds the ids
+// that were changed, Missing the requested ids no task carries, Blocked the
+// tasks left untouched because of their subtasks, Rejected those the workflow
+// does not let change and Created the tasks added along the way, such as next
+// occurrences of recurring tasks.
+type BulkResult struct {
+	Affected []int
+	Missing  []int
+	Blocked  []int
+	Rejected []int
+	Created  []int
+}
+
+func newBulkResult() BulkResult {
+	return BulkResult{Affected: []int{}, Missing: []int{}, Blocked: []int{}, Rejected: []int{}, Created: []int{}}
+}
+
+// ParseIDList reads comma-separated ids and inclusive ranges such as
+// "3,5,7-12", dropping duplicates while keeping the first-seen order.
+func ParseIDList(value string) ([]int, error) {
+	ids := []int{}
+	seen := map[int]bool{}
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || start < 0 {
+			return []int{}, fmt.Errorf("invalid id %q", part)
+		}
+		if !isRange {
+			add(start)
+			continue
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(to))
+		if err != nil || end < start {
+			return []int{}, fmt.Errorf("invalid id range %q", part)
+		}
+		if end-start >= maxIDRange {
+			return []int{}, fmt.Errorf("id range %q is longer than %d", part, maxIDRange)
+		}
+		for id := start; id <= end; id++ {
+			add(id)
+		}
+	}
+	if len(ids) == 0 {
+		return []int{}, fmt.Errorf("no ids given")
+	}
+	return ids, nil
+}
+
+func CompleteMany(list TaskList, ids []int) (TaskList, BulkResult) {
+	return SetStatusMany(list, ids, StatusDone)
+}
+
+// SetStatusMany moves the requested tasks to status. A task with open
+// subtasks cannot be closed unless all of them are requested as well.
+func SetStatusMany(list TaskList, ids []int, status Status) (TaskList, BulkResult) {
+	result := newBulkResult()
+	positions := indexByID(list.Tasks)
+	requested := map[int]bool{}
+	for _, id := range ids {
+		requested[id] = true
+	}
+	now := Now()
+	for _, id := range ids {
+		i, ok := positions[id]
+		if !ok {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+		err := checkStatusChange(list.Tasks, list.Tasks[i], status, func(id int) bool { return requested[id] })
+		switch {
+		case errors.Is(err, ErrOpenSubtasks):
+			result.Blocked = append(result.Blocked, id)
+			continue
+		case err != nil:
+			result.Rejected = append(result.Rejected, id)
+			continue
+		}
+		var created int
+		if list, created = setStatusAt(list, i, status, now); created >= 0 {
+			result.Created = append(result.Created, created)
+		}
+		result.Affected = append(result.Affected, id)
+	}
+	logBulkResult("set status "+string(status), result)
+	return list, result
+}
+
+// DeleteMany removes the requested tasks. With ChildrenCascade their subtasks
+// are removed and reported as affected too, otherwise the surviving subtasks
+// lose their parent. Dependencies on removed tasks are dropped.
+func DeleteMany(tasks []Task, ids []int, policy ChildPolicy) ([]Task, BulkResult) {
+	remaining, result := removeTasks(tasks, ids, policy)
+	logBulkResult("delete", result)
+	return remaining, result
+}
+
+func removeTasks(tasks []Task, ids []int, policy ChildPolicy) ([]Task, BulkResult) {
+	result := newBulkResult()
+	positions := indexByID(tasks)
+	deleted := map[int]bool{}
+	for _, id := range ids {
+		if _, ok := positions[id]; !ok {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+		if !deleted[id] {
+			deleted[id] = true
+			result.Affected = append(result.Affected, id)
+		}
+		if policy != ChildrenCascade {
+			continue
+		}
+		for _, descendant := range descendants(childrenByParent(tasks), id) {
+			if !deleted[descendant.ID] {
+				deleted[descendant.ID] = true
+				result.Affected = append(result.Affected, descendant.ID)
+			}
+		}
+	}
+	remaining := []Task{}
+	now := Now()
+	for _, task := range tasks {
+		if deleted[task.ID] {
+			continue
+		}
+		if task.Parent != nil && deleted[*task.Parent] {
+			task.Parent = nil
+			task.UpdatedAt = &now
+		}
+		if slices.ContainsFunc(task.DependsOn, func(id int) bool { return deleted[id] }) {
+			task.DependsOn = normalizeIDs(slices.DeleteFunc(slices.Clone(task.DependsOn), func(id int) bool { return deleted[id] }))
+			task.UpdatedAt = &now
+		}
+		remaining = append(remaining, task)
+	}
+	return remaining, result
+}
+
+func indexByID(tasks []Task) map[int]int {
+	positions := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		positions[task.ID] = i
+	}
+	return positions
+}
+
+func logBulkResult(operation string, result BulkResult) {
+	if len(result.Missing) > 0 {
+		logging.Logger.Debug("Some of the requested tasks are missing", "operation", operation, "missing", result.Missing)
+	}
+	if len(result.Blocked) > 0 {
+		logging.Logger.Debug("Some of the requested tasks have open subtasks", "operation", operation, "blocked", result.Blocked)
+	}
+	if len(result.Rejected) > 0 {
+		logging.Logger.Debug("The workflow rejected some of the requested tasks", "operation", operation, "rejected", result.Rejected)
+	}
+	logging.Logger.Debug("Bulk operation finished", "operation", operation, "affected", len(result.Affected))
+}
@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseIDList(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      []int
+		errorExpected bool
+	}{
+		{"3", []int{3}, false},
+		{"3,5,7-12", []int{3, 5, 7, 8, 9, 10, 11, 12}, false},
+		{" 2 , 1-3 ,", []int{2, 1, 3}, false},
+		{"4-4", []int{4}, false},
+		{"", []int{}, true},
+		{"a", []int{}, true},
+		{"-1", []int{}, true},
+		{"5-3", []int{}, true},
+		{"1-x", []int{}, true},
+		{"0-100000", []int{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseIDList(tt.input)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompleteMany(t *testing.T) {
+	list := NewTaskList(append([]Task{}, testTasks...), 0)
+	updatedList, result := CompleteMany(list, []int{0, 7, 2})
+	updatedTasks := updatedList.Tasks
+	if !slices.Equal(result.Affected, []int{0, 2}) || !slices.Equal(result.Missing, []int{7}) {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+	for _, task := range updatedTasks {
+		if task.Status != StatusDone {
+			t.Errorf("Test failed: task %d was not marked as done", task.ID)
+		}
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	tasks := append([]Task{}, testTasks...)
+	updatedTasks, result := DeleteMany(tasks, []int{2, 9, 0}, ChildrenOrphan)
+	if !slices.Equal(result.Affected, []int{2, 0}) || !slices.Equal(result.Missing, []int{9}) {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+	if len(updatedTasks) != 1 || updatedTasks[0].ID != 1 {
+		t.Errorf("Test failed: unexpected remaining tasks %v", updatedTasks)
+	}
+	if len(testTasks) != 3 || testTasks[0].ID != 0 {
+		t.Errorf("Test failed: the input slice was modified: %v", testTasks)
+	}
+}
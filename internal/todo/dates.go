@@ -0,0 +1,134 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DateLayout string = "2006-01-02"
+
+const UpcomingDays int = 7
+
+// Now is the clock used for the task timestamps and everything relative to
+// the current date. Tests replace it to get deterministic results.
+var Now = time.Now
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func SameDay(a, b time.Time) bool {
+	return StartOfDay(a).Equal(StartOfDay(b.In(a.Location())))
+}
+
+// ParseDate understands YYYY-MM-DD, today/tomorrow/yesterday, weekday names
+// optionally prefixed with "next" (the first such day after today), "next
+// week"/"next month" and offsets like +3d, -2w, +1m, +1y. The result is the
+// start of the day in now's location.
+func ParseDate(input string, now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	today := StartOfDay(now)
+	switch value {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date")
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "next week":
+		return today.AddDate(0, 0, 7), nil
+	case "next month":
+		return today.AddDate(0, 1, 0), nil
+	}
+	if weekday, ok := weekdays[strings.TrimPrefix(value, "next ")]; ok {
+		days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+		return today.AddDate(0, 0, days), nil
+	}
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		return parseOffset(value, today)
+	}
+	parsed, err := time.ParseInLocation(DateLayout, value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognised date %q, expected YYYY-MM-DD, a weekday, today, tomorrow or an offset like +3d", input)
+	}
+	return parsed, nil
+}
+
+// ParsePastDate is ParseDate for the lower bound of a period: weekday names
+// resolve to the latest such day up to and including today, and "last week"
+// and "last month" count back from today.
+func ParsePastDate(input string, now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	today := StartOfDay(now)
+	switch value {
+	case "last week":
+		return today.AddDate(0, 0, -7), nil
+	case "last month":
+		return today.AddDate(0, -1, 0), nil
+	}
+	if weekday, ok := weekdays[strings.TrimPrefix(value, "last ")]; ok {
+		days := (int(today.Weekday()) - int(weekday) + 7) % 7
+		return today.AddDate(0, 0, -days), nil
+	}
+	return ParseDate(input, now)
+}
+
+var TimestampFields = map[string]func(Task) *time.Time{
+	"created":   func(t Task) *time.Time { return t.CreatedAt },
+	"updated":   func(t Task) *time.Time { return t.UpdatedAt },
+	"completed": func(t Task) *time.Time { return t.CompletedAt },
+	"archived":  func(t Task) *time.Time { return t.ArchivedAt },
+}
+
+// InPeriod reports whether value lies in [since, until); nil bounds are open
+// and an unset value is never in a bounded period.
+func InPeriod(value *time.Time, since *time.Time, until *time.Time) bool {
+	if value == nil {
+		return since == nil && until == nil
+	}
+	return (since == nil || !value.Before(*since)) && (until == nil || value.Before(*until))
+}
+
+// ParseAge reads an age such as 90d, 2w, 6m or 1y and returns the start of
+// the day that long before now.
+func ParseAge(input string, now time.Time) (time.Time, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	if value == "" || strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		return time.Time{}, fmt.Errorf("invalid age %q, expected something like 90d", input)
+	}
+	return parseOffset("-"+value, StartOfDay(now))
+}
+
+func parseOffset(value string, today time.Time) (time.Time, error) {
+	unit := value[len(value)-1]
+	amount, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || len(value) < 3 {
+		return time.Time{}, fmt.Errorf("invalid date offset %q, expected something like +3d", value)
+	}
+	switch unit {
+	case 'd':
+		return today.AddDate(0, 0, amount), nil
+	case 'w':
+		return today.AddDate(0, 0, 7*amount), nil
+	case 'm':
+		return today.AddDate(0, amount, 0), nil
+	case 'y':
+		return today.AddDate(amount, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date offset unit %q, expected one of d, w, m, y", string(unit))
+}
@@ -0,0 +1,131 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC) // a Sunday
+	tests := []struct {
+		input         string
+		expected      string
+		errorExpected bool
+	}{
+		{"today", "2026-10-18", false},
+		{"Tomorrow", "2026-10-19", false},
+		{"yesterday", "2026-10-17", false},
+		{"fri", "2026-10-23", false},
+		{"next fri", "2026-10-23", false},
+		{"sunday", "2026-10-25", false},
+		{"next week", "2026-10-25", false},
+		{"+3d", "2026-10-21", false},
+		{"-2w", "2026-10-04", false},
+		{"+1m", "2026-11-18", false},
+		{"+1y", "2027-10-18", false},
+		{"2026-12-31", "2026-12-31", false},
+		{"", "", true},
+		{"+3x", "", true},
+		{"+d", "", true},
+		{"someday", "", true},
+		{"2026-13-01", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDate(tt.input, now)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && got.Format(DateLayout) != tt.expected {
+				t.Errorf("Test failed: got %s, expected %s", got.Format(DateLayout), tt.expected)
+			}
+			if err == nil && !got.Equal(StartOfDay(got)) {
+				t.Errorf("Test failed: %v is not the start of a day", got)
+			}
+		})
+	}
+}
+
+func TestParsePastDate(t *testing.T) {
+	now := time.Date(2026, 10, 21, 15, 30, 0, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"monday", "2026-10-19"},
+		{"wed", "2026-10-21"},
+		{"last thu", "2026-10-15"},
+		{"last week", "2026-10-14"},
+		{"last month", "2026-09-21"},
+		{"-3d", "2026-10-18"},
+		{"2026-10-01", "2026-10-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePastDate(tt.input, now)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if got.Format(DateLayout) != tt.expected {
+				t.Errorf("Test failed: got %s, expected %s", got.Format(DateLayout), tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	now := time.Date(2026, 10, 21, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		input         string
+		expected      string
+		errorExpected bool
+	}{
+		{"90d", "2026-07-23", false},
+		{"2W", "2026-10-07", false},
+		{"6m", "2026-04-21", false},
+		{"-3d", "", true},
+		{"+3d", "", true},
+		{"d", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAge(tt.input, now)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && got.Format(DateLayout) != tt.expected {
+				t.Errorf("Test failed: got %s, expected %s", got.Format(DateLayout), tt.expected)
+			}
+		})
+	}
+}
+
+func TestInPeriod(t *testing.T) {
+	at := func(day int) *time.Time {
+		value := time.Date(2026, 10, day, 12, 0, 0, 0, time.UTC)
+		return &value
+	}
+	tests := []struct {
+		name     string
+		value    *time.Time
+		since    *time.Time
+		until    *time.Time
+		expected bool
+	}{
+		{"open period", at(10), nil, nil, true},
+		{"unset value in an open period", nil, nil, nil, true},
+		{"unset value in a bounded period", nil, at(1), nil, false},
+		{"inside", at(10), at(9), at(11), true},
+		{"since is inclusive", at(10), at(10), nil, true},
+		{"until is exclusive", at(10), nil, at(10), false},
+		{"before", at(8), at(9), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InPeriod(tt.value, tt.since, tt.until); got != tt.expected {
+				t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package todo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EstimateUnit string
+
+const (
+	Hours  EstimateUnit = "h"
+	Points EstimateUnit = "pt"
+)
+
+// maxEstimate bounds an estimate, in hours or points, so that a plan made
+// from it stays a sensible number of days.
+const maxEstimate = 10000
+
+// pointSuffixes mark an estimate in story points; anything else is a duration.
+var pointSuffixes = []string{"points", "point", "pts", "pt", "sp", "p"}
+
+// Estimate is the expected effort of a task, in hours or story points.
+type Estimate struct {
+	Amount float64
+	Unit   EstimateUnit
+}
+
+// ParseEstimate reads hours as a duration (4h, 1h30m, 90m) or a bare number,
+// and story points with a suffix (5pt, 3sp, 8 points).
+func ParseEstimate(value string) (Estimate, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+	estimate := Estimate{Unit: Hours}
+	for _, suffix := range pointSuffixes {
+		if number, ok := strings.CutSuffix(text, suffix); ok {
+			text, estimate.Unit = strings.TrimSpace(number), Points
+			break
+		}
+	}
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil && estimate.Unit == Hours {
+		var duration time.Duration
+		if duration, err = time.ParseDuration(text); err == nil {
+			amount = duration.Hours()
+		}
+	}
+	if err != nil || math.IsInf(amount, 0) || math.IsNaN(amount) || amount <= 0 || amount > maxEstimate {
+		return Estimate{}, fmt.Errorf("invalid estimate %q, expected hours such as 4h or 1h30m, or points such as 5pt, up to %d", value, maxEstimate)
+	}
+	// hours are kept to whole minutes, as String shows them, and points to hundredths
+	if estimate.Unit == Hours {
+		amount = time.Duration(amount * float64(time.Hour)).Round(time.Minute).Hours()
+	} else {
+		amount = math.Round(amount*100) / 100
+	}
+	if amount <= 0 {
+		return Estimate{}, fmt.Errorf("invalid estimate %q, less than a minute or a hundredth of a point", value)
+	}
+	estimate.Amount = amount
+	return estimate, nil
+}
+
+func (e Estimate) String() string {
+	if e.Unit == Points {
+		return strconv.FormatFloat(e.Amount, 'f', -1, 64) + string(Points)
+	}
+	return FormatDuration(time.Duration(e.Amount * float64(time.Hour)))
+}
+
+func (e Estimate) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *Estimate) UnmarshalText(text []byte) error {
+	estimate, err := ParseEstimate(string(text))
+	if err != nil {
+		return err
+	}
+	*e = estimate
+	return nil
+}
+
+// Remaining returns the effort left on task in unit: hour estimates are
+// reduced by the time logged on the task, down to zero. The second result is
+// false when the task has no estimate in unit.
+func Remaining(task Task, unit EstimateUnit, now time.Time) (float64, bool) {
+	if task.Estimate == nil || task.Estimate.Unit != unit {
+		return 0, false
+	}
+	if unit == Points {
+		return task.Estimate.Amount, true
+	}
+	return max(task.Estimate.Amount-Logged(task, nil, nil, now).Hours(), 0), true
+}
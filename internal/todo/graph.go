@@ -0,0 +1,186 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Graph indexes tasks by id to answer questions about their dependencies.
+// References to tasks missing from the graph are ignored.
+type Graph struct {
+	tasks map[int]Task
+}
+
+func NewGraph(tasks []Task) Graph {
+	graph := Graph{tasks: make(map[int]Task, len(tasks))}
+	for _, task := range tasks {
+		graph.tasks[task.ID] = task
+	}
+	return graph
+}
+
+// Blockers returns the open tasks task depends on; a cancelled dependency no
+// longer blocks.
+func (g Graph) Blockers(task Task) []int {
+	blockers := []int{}
+	for _, id := range task.DependsOn {
+		if dependency, ok := g.tasks[id]; ok && !dependency.Status.Closed() {
+			blockers = append(blockers, id)
+		}
+	}
+	return blockers
+}
+
+// Ready reports whether task is open and none of its dependencies is.
+func (g Graph) Ready(task Task) bool {
+	return !task.Status.Closed() && len(g.Blockers(task)) == 0
+}
+
+// Check verifies that task id may depend on dependencies: every one has to
+// exist and none may already depend on id, directly or through others.
+func (g Graph) Check(id int, dependencies []int) error {
+	for _, dependency := range dependencies {
+		if dependency == id {
+			return fmt.Errorf("task id=%d cannot depend on itself", id)
+		}
+		if _, ok := g.tasks[dependency]; !ok {
+			return fmt.Errorf("dependency task id=%d is missing", dependency)
+		}
+		if path := g.path(dependency, id); path != nil {
+			return fmt.Errorf("task id=%d cannot depend on %d: dependency cycle %s", id, dependency, formatCycle(append([]int{id}, path...)))
+		}
+	}
+	return nil
+}
+
+// path returns a dependency chain leading from one task to another, or nil.
+func (g Graph) path(from int, to int) []int {
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []int{current}
+			for current != from {
+				current = previous[current]
+				path = append([]int{current}, path...)
+			}
+			return path
+		}
+		for _, next := range g.tasks[current].DependsOn {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// cycle returns a dependency cycle among the tasks, or nil when there is none.
+func (g Graph) cycle() []int {
+	ids := make([]int, 0, len(g.tasks))
+	for id := range g.tasks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		for _, dependency := range g.tasks[id].DependsOn {
+			if path := g.path(dependency, id); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+	}
+	return nil
+}
+
+// TopoSort reorders tasks so that every task comes after the tasks it depends
+// on, otherwise keeping their order: of the tasks free to go next the one
+// listed first wins. Tasks on a cycle keep their order at the end.
+func TopoSort(tasks []Task) {
+	position := make(map[int]int, len(tasks))
+	for i, task := range tasks {
+		position[task.ID] = i
+	}
+	waitingFor := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, task := range tasks {
+		for _, id := range task.DependsOn {
+			if j, ok := position[id]; ok && j != i {
+				waitingFor[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+	// free holds positions ready to go, kept sorted so the earliest goes first
+	free := []int{}
+	for i := range tasks {
+		if waitingFor[i] == 0 {
+			free = append(free, i)
+		}
+	}
+	ordered := make([]Task, 0, len(tasks))
+	placed := make([]bool, len(tasks))
+	for len(free) > 0 {
+		i := free[0]
+		free = free[1:]
+		ordered = append(ordered, tasks[i])
+		placed[i] = true
+		for _, dependent := range dependents[i] {
+			if waitingFor[dependent]--; waitingFor[dependent] == 0 {
+				at, _ := slices.BinarySearch(free, dependent)
+				free = slices.Insert(free, at, dependent)
+			}
+		}
+	}
+	for i, task := range tasks {
+		if !placed[i] {
+			ordered = append(ordered, task)
+		}
+	}
+	copy(tasks, ordered)
+}
+
+// ValidateDependencies checks the dependencies task id is about to get
+// against the other tasks.
+func ValidateDependencies(tasks []Task, id int, dependencies []int) error {
+	return NewGraph(tasks).Check(id, dependencies)
+}
+
+// validateDependencies reports references to missing tasks and cycles.
+func validateDependencies(tasks []Task) error {
+	graph := NewGraph(tasks)
+	for _, task := range tasks {
+		for _, id := range task.DependsOn {
+			if _, ok := graph.tasks[id]; !ok {
+				return fmt.Errorf("task id=%d: dependency task id=%d is missing", task.ID, id)
+			}
+		}
+	}
+	if cycle := graph.cycle(); cycle != nil {
+		return fmt.Errorf("dependency cycle %s", formatCycle(cycle))
+	}
+	return nil
+}
+
+// formatCycle renders 1 -> 2 -> 1 for a cycle given as [1 2 1].
+func formatCycle(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// normalizeIDs sorts ids and drops duplicates; an empty result is nil.
+func normalizeIDs(ids []int) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
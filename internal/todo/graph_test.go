@@ -0,0 +1,129 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+)
+
+// dependencyTasks builds 3 -> (1, 2), 2 -> 0 where 1 is done.
+func dependencyTasks() []Task {
+	return []Task{
+		{ID: 0, Description: "Design", Status: StatusTodo},
+		{ID: 1, Description: "Schema", Status: StatusDone},
+		{ID: 2, Description: "API", Status: StatusTodo, DependsOn: []int{0}},
+		{ID: 3, Description: "Release", Status: StatusTodo, DependsOn: []int{1, 2}},
+	}
+}
+
+func taskIDs(tasks []Task) []int {
+	ids := []int{}
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func TestGraphCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            int
+		dependencies  []int
+		errorExpected bool
+	}{
+		{"valid", 0, []int{1}, false},
+		{"new task", 4, []int{3, 0}, false},
+		{"itself", 2, []int{2}, true},
+		{"missing", 2, []int{9}, true},
+		{"direct cycle", 0, []int{2}, true},
+		{"indirect cycle", 0, []int{3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDependencies(dependencyTasks(), tt.id, tt.dependencies)
+			if (err != nil) != tt.errorExpected {
+				t.Errorf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+		})
+	}
+}
+
+func TestDependencyFilters(t *testing.T) {
+	tasks := dependencyTasks()
+	if ids := taskIDs(List(tasks, string(FilterReady))); !slices.Equal(ids, []int{0}) {
+		t.Errorf("Test failed: ready tasks %v, expected [0]", ids)
+	}
+	if ids := taskIDs(List(tasks, string(FilterBlocked))); !slices.Equal(ids, []int{2, 3}) {
+		t.Errorf("Test failed: blocked tasks %v, expected [2 3]", ids)
+	}
+	tasks[0].Status = StatusDone
+	if ids := taskIDs(List(tasks, string(FilterReady))); !slices.Equal(ids, []int{2}) {
+		t.Errorf("Test failed: ready tasks %v, expected [2]", ids)
+	}
+}
+
+func TestTopoSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    []int
+		expected []int
+	}{
+		{"already ordered", []int{0, 1, 2, 3}, []int{0, 1, 2, 3}},
+		{"reversed", []int{3, 2, 1, 0}, []int{1, 0, 2, 3}},
+		{"keeps free tasks in place", []int{1, 3, 0, 2}, []int{1, 0, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all := dependencyTasks()
+			tasks := []Task{}
+			for _, id := range tt.order {
+				tasks = append(tasks, all[id])
+			}
+			TopoSort(tasks)
+			if ids := taskIDs(tasks); !slices.Equal(ids, tt.expected) {
+				t.Errorf("Test failed: got %v, expected %v", ids, tt.expected)
+			}
+		})
+	}
+
+	t.Run("sort key", func(t *testing.T) {
+		tasks := dependencyTasks()
+		keys, err := ParseSort("topo,-id")
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		Sort(tasks, keys...)
+		if ids := taskIDs(tasks); !slices.Equal(ids, []int{1, 0, 2, 3}) {
+			t.Errorf("Test failed: got %v, expected [1 0 2 3]", ids)
+		}
+	})
+}
+
+func TestValidateDependencyReferences(t *testing.T) {
+	dangling := dependencyTasks()
+	dangling[2].DependsOn = []int{7}
+	cyclic := dependencyTasks()
+	cyclic[0].DependsOn = []int{3}
+	for name, tasks := range map[string][]Task{"dangling": dangling, "cycle": cyclic} {
+		if err := Validate(NewTaskList(tasks, 0)); err == nil {
+			t.Errorf("Test failed: %s dependencies were accepted", name)
+		}
+	}
+}
+
+func TestDependencyUpdates(t *testing.T) {
+	tasks, err := Update(dependencyTasks(), 2, Patch{AddDependencies: []int{1, 1}, RemoveDependencies: []int{0}})
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if !slices.Equal(tasks[2].DependsOn, []int{1}) {
+		t.Errorf("Test failed: unexpected dependencies %v", tasks[2].DependsOn)
+	}
+	if _, err := Update(dependencyTasks(), 0, Patch{AddDependencies: []int{3}}); err == nil {
+		t.Error("Test failed: accepted a dependency cycle")
+	}
+
+	remaining, _ := DeleteMany(dependencyTasks(), []int{1}, ChildrenOrphan)
+	if !slices.Equal(remaining[2].DependsOn, []int{2}) {
+		t.Errorf("Test failed: the dependency on a deleted task was kept: %v", remaining[2].DependsOn)
+	}
+}
@@ -0,0 +1,170 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ChildPolicy decides what deleting a task does to its subtasks.
+type ChildPolicy string
+
+const (
+	// ChildrenOrphan keeps the subtasks as top-level tasks.
+	ChildrenOrphan ChildPolicy = "orphan"
+	// ChildrenCascade deletes the whole subtree.
+	ChildrenCascade ChildPolicy = "cascade"
+)
+
+func ChildPolicies() []ChildPolicy {
+	return []ChildPolicy{ChildrenOrphan, ChildrenCascade}
+}
+
+func ParseChildPolicy(value string) (ChildPolicy, error) {
+	policy := ChildPolicy(value)
+	if !slices.Contains(ChildPolicies(), policy) {
+		return "", fmt.Errorf("invalid children policy %q, expected orphan or cascade", value)
+	}
+	return policy, nil
+}
+
+// TreeNode is a task placed in the hierarchy: Depth is 0 for top-level tasks,
+// Done and Total count the task's subtasks at any depth, leaving cancelled
+// ones out.
+type TreeNode struct {
+	Task  Task
+	Depth int
+	Done  int
+	Total int
+}
+
+// Tree orders tasks depth-first, each subtask right after its parent, keeping
+// the given order among siblings. Tasks whose parent is not among tasks are
+// shown at the top level. Progress is counted over all, so that a filtered
+// listing still reports every subtask.
+func Tree(tasks []Task, all []Task) []TreeNode {
+	shown := map[int]bool{}
+	for _, task := range tasks {
+		shown[task.ID] = true
+	}
+	children := map[int][]Task{}
+	roots := []Task{}
+	for _, task := range tasks {
+		if task.Parent != nil && shown[*task.Parent] && *task.Parent != task.ID {
+			children[*task.Parent] = append(children[*task.Parent], task)
+		} else {
+			roots = append(roots, task)
+		}
+	}
+	allChildren := childrenByParent(all)
+	nodes := make([]TreeNode, 0, len(tasks))
+	visited := map[int]bool{}
+	var walk func(task Task, depth int)
+	walk = func(task Task, depth int) {
+		if visited[task.ID] {
+			return
+		}
+		visited[task.ID] = true
+		node := TreeNode{Task: task, Depth: depth}
+		for _, descendant := range descendants(allChildren, task.ID) {
+			if descendant.Status == StatusCancelled {
+				continue
+			}
+			node.Total++
+			if descendant.Status == StatusDone {
+				node.Done++
+			}
+		}
+		nodes = append(nodes, node)
+		for _, child := range children[task.ID] {
+			walk(child, depth+1)
+		}
+	}
+	for _, task := range roots {
+		walk(task, 0)
+	}
+	return nodes
+}
+
+// ValidateParent checks that task id may be placed under parent: the parent
+// has to exist and must not be the task itself or one of its subtasks.
+func ValidateParent(tasks []Task, id int, parent int) error {
+	if parent == id {
+		return fmt.Errorf("task id=%d cannot be its own parent", id)
+	}
+	if !slices.ContainsFunc(tasks, func(t Task) bool { return t.ID == parent }) {
+		return fmt.Errorf("parent task id=%d is missing", parent)
+	}
+	for _, descendant := range descendants(childrenByParent(tasks), id) {
+		if descendant.ID == parent {
+			return fmt.Errorf("task id=%d cannot be moved under its own subtask id=%d", id, parent)
+		}
+	}
+	return nil
+}
+
+// OpenSubtasks returns the ids of the subtasks of task id at any depth that
+// are not closed.
+func OpenSubtasks(tasks []Task, id int) []int {
+	ids := []int{}
+	for _, descendant := range descendants(childrenByParent(tasks), id) {
+		if !descendant.Status.Closed() {
+			ids = append(ids, descendant.ID)
+		}
+	}
+	return ids
+}
+
+func childrenByParent(tasks []Task) map[int][]Task {
+	children := map[int][]Task{}
+	for _, task := range tasks {
+		if task.Parent != nil {
+			children[*task.Parent] = append(children[*task.Parent], task)
+		}
+	}
+	return children
+}
+
+// descendants walks the subtree below id breadth-first; a task reachable
+// twice through a broken hierarchy is reported once.
+func descendants(children map[int][]Task, id int) []Task {
+	result := []Task{}
+	seen := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			result = append(result, child)
+			queue = append(queue, child.ID)
+		}
+	}
+	return result
+}
+
+// validateHierarchy reports parents that do not exist and cycles.
+func validateHierarchy(tasks []Task) error {
+	parents := make(map[int]*int, len(tasks))
+	for _, task := range tasks {
+		parents[task.ID] = task.Parent
+	}
+	for _, task := range tasks {
+		if task.Parent == nil {
+			continue
+		}
+		if _, ok := parents[*task.Parent]; !ok {
+			return fmt.Errorf("task id=%d: parent task id=%d is missing", task.ID, *task.Parent)
+		}
+		seen := map[int]bool{task.ID: true}
+		for parent := task.Parent; parent != nil; parent = parents[*parent] {
+			if seen[*parent] {
+				return fmt.Errorf("task id=%d: parent chain forms a cycle", task.ID)
+			}
+			seen[*parent] = true
+		}
+	}
+	return nil
+}
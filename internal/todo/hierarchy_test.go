@@ -0,0 +1,127 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// hierarchyTasks builds 0 -> (1 -> 3, 2) and a separate task 4.
+func hierarchyTasks() []Task {
+	return []Task{
+		{ID: 0, Description: "Release", Status: StatusTodo},
+		{ID: 1, Description: "Backend", Status: StatusTodo, Parent: intPtr(0)},
+		{ID: 2, Description: "Docs", Parent: intPtr(0), Status: StatusDone},
+		{ID: 3, Description: "Migration", Status: StatusTodo, Parent: intPtr(1)},
+		{ID: 4, Description: "Unrelated", Status: StatusTodo},
+	}
+}
+
+func treeIDs(nodes []TreeNode) []int {
+	ids := []int{}
+	for _, node := range nodes {
+		ids = append(ids, node.Task.ID)
+	}
+	return ids
+}
+
+func TestTree(t *testing.T) {
+	tasks := hierarchyTasks()
+	nodes := Tree([]Task{tasks[3], tasks[4], tasks[2], tasks[1], tasks[0]}, tasks)
+	if ids := treeIDs(nodes); !slices.Equal(ids, []int{4, 0, 2, 1, 3}) {
+		t.Fatalf("Test failed: unexpected order %v", ids)
+	}
+	expected := []struct{ depth, done, total int }{{0, 0, 0}, {0, 1, 3}, {1, 0, 0}, {1, 0, 1}, {2, 0, 0}}
+	for i, node := range nodes {
+		if node.Depth != expected[i].depth || node.Done != expected[i].done || node.Total != expected[i].total {
+			t.Errorf("Test failed: task %d got depth %d and %d/%d", node.Task.ID, node.Depth, node.Done, node.Total)
+		}
+	}
+
+	t.Run("filtered", func(t *testing.T) {
+		nodes := Tree([]Task{tasks[0], tasks[3]}, tasks)
+		if nodes[1].Depth != 0 || nodes[0].Total != 3 {
+			t.Errorf("Test failed: unexpected nodes %+v", nodes)
+		}
+	})
+}
+
+func TestValidateParent(t *testing.T) {
+	tests := []struct {
+		name          string
+		id            int
+		parent        int
+		errorExpected bool
+	}{
+		{"valid", 4, 3, false},
+		{"move up", 3, 0, false},
+		{"itself", 1, 1, true},
+		{"missing", 4, 9, true},
+		{"own subtask", 0, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateParent(hierarchyTasks(), tt.id, tt.parent); (err != nil) != tt.errorExpected {
+				t.Errorf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+		})
+	}
+}
+
+func TestCompleteWithSubtasks(t *testing.T) {
+	if _, err := Complete(NewTaskList(hierarchyTasks(), 0), 0); err == nil {
+		t.Error("Test failed: completed a task with open subtasks")
+	}
+	if _, err := Complete(NewTaskList(hierarchyTasks(), 0), 3); err != nil {
+		t.Errorf("Test failed: Unexpected error: %v", err)
+	}
+
+	list, result := CompleteMany(NewTaskList(hierarchyTasks(), 0), []int{0, 1})
+	tasks := list.Tasks
+	if !slices.Equal(result.Blocked, []int{0, 1}) || len(result.Affected) != 0 {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+	if tasks[0].Status == StatusDone || tasks[1].Status == StatusDone {
+		t.Error("Test failed: a blocked task was completed")
+	}
+
+	_, result = CompleteMany(NewTaskList(hierarchyTasks(), 0), []int{0, 1, 3})
+	if !slices.Equal(result.Affected, []int{0, 1, 3}) || len(result.Blocked) != 0 {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+}
+
+func TestDeleteWithSubtasks(t *testing.T) {
+	t.Run("orphan", func(t *testing.T) {
+		tasks, result := DeleteMany(hierarchyTasks(), []int{0}, ChildrenOrphan)
+		if !slices.Equal(result.Affected, []int{0}) || len(tasks) != 4 {
+			t.Fatalf("Test failed: unexpected result %+v, tasks %v", result, tasks)
+		}
+		if tasks[0].Parent != nil || tasks[1].Parent != nil || tasks[2].Parent == nil {
+			t.Errorf("Test failed: unexpected parents %v", tasks)
+		}
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		tasks, result := DeleteMany(hierarchyTasks(), []int{0}, ChildrenCascade)
+		if !slices.Equal(result.Affected, []int{0, 1, 2, 3}) || len(tasks) != 1 || tasks[0].ID != 4 {
+			t.Errorf("Test failed: unexpected result %+v, tasks %v", result, tasks)
+		}
+	})
+}
+
+func TestUpdateParent(t *testing.T) {
+	if _, err := Update(hierarchyTasks(), 0, Patch{Parent: intPtr(3)}); err == nil {
+		t.Error("Test failed: moved a task under its own subtask")
+	}
+	tasks, err := Update(hierarchyTasks(), 3, Patch{Parent: intPtr(-1)})
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if tasks[3].Parent != nil {
+		t.Errorf("Test failed: parent was not cleared: %v", tasks[3])
+	}
+}
@@ -0,0 +1,225 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxJournalOperations bounds the journal; the oldest operations are dropped
+// first.
+const MaxJournalOperations int = 100
+
+// Change is a task before and after an operation, nil when it did not exist
+// on that side. Archived tasks are told apart by their ArchivedAt.
+type Change struct {
+	Before *Task `json:",omitempty"`
+	After  *Task `json:",omitempty"`
+}
+
+func (c Change) id() int {
+	if c.Before != nil {
+		return c.Before.ID
+	}
+	return c.After.ID
+}
+
+// Operation is a recorded command together with the changes it made.
+type Operation struct {
+	Time    time.Time
+	Command string
+	Changes []Change
+	Undone  bool `json:",omitempty"`
+}
+
+// Inverse returns the operation that reverts op.
+func (op Operation) Inverse() Operation {
+	inverse := op
+	inverse.Changes = make([]Change, len(op.Changes))
+	for i, change := range op.Changes {
+		inverse.Changes[len(op.Changes)-1-i] = Change{Before: change.After, After: change.Before}
+	}
+	return inverse
+}
+
+// Summary counts the tasks the operation added, changed and removed.
+func (op Operation) Summary() string {
+	var added, changed, removed int
+	for _, change := range op.Changes {
+		switch {
+		case change.Before == nil:
+			added++
+		case change.After == nil:
+			removed++
+		default:
+			changed++
+		}
+	}
+	parts := []string{}
+	for _, count := range []struct {
+		amount int
+		verb   string
+	}{{added, "added"}, {changed, "changed"}, {removed, "removed"}} {
+		if count.amount > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.amount, count.verb))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Journal lists the operations oldest first. Undone operations are always
+// the trailing ones and are dropped when a new operation is recorded.
+type Journal struct {
+	Operations []Operation
+}
+
+// Record appends op, forgetting the undone operations it replaces.
+func (j Journal) Record(op Operation) Journal {
+	operations := append(slices.Clone(j.Operations[:j.done()]), op)
+	if len(operations) > MaxJournalOperations {
+		operations = operations[len(operations)-MaxJournalOperations:]
+	}
+	return Journal{Operations: operations}
+}
+
+// Undo reverts the last n operations that are not undone yet, newest first,
+// and returns them in that order.
+func (j Journal) Undo(list TaskList, n int) (TaskList, Journal, []Operation, error) {
+	done := j.done()
+	if n < 1 || n > done {
+		return list, j, nil, fmt.Errorf("cannot undo %d operation(s), %d can be undone", n, done)
+	}
+	original := list
+	operations := slices.Clone(j.Operations)
+	undone := []Operation{}
+	for i := done - 1; i >= done-n; i-- {
+		var err error
+		if list, err = ApplyChanges(list, operations[i].Inverse().Changes); err != nil {
+			return original, j, nil, fmt.Errorf("cannot undo %q: %w", operations[i].Command, err)
+		}
+		operations[i].Undone = true
+		undone = append(undone, operations[i])
+	}
+	return list, Journal{Operations: operations}, undone, nil
+}
+
+// Redo applies again the oldest undone operation.
+func (j Journal) Redo(list TaskList) (TaskList, Journal, Operation, error) {
+	done := j.done()
+	if done == len(j.Operations) {
+		return list, j, Operation{}, fmt.Errorf("nothing to redo")
+	}
+	operations := slices.Clone(j.Operations)
+	list, err := ApplyChanges(list, operations[done].Changes)
+	if err != nil {
+		return list, j, Operation{}, fmt.Errorf("cannot redo %q: %w", operations[done].Command, err)
+	}
+	operations[done].Undone = false
+	return list, Journal{Operations: operations}, operations[done], nil
+}
+
+// done counts the operations that are not undone.
+func (j Journal) done() int {
+	done := len(j.Operations)
+	for done > 0 && j.Operations[done-1].Undone {
+		done--
+	}
+	return done
+}
+
+// Diff returns the changes turning before into after, ordered by task id.
+func Diff(before TaskList, after TaskList) []Change {
+	old := tasksByID(before)
+	changes := []Change{}
+	for id, task := range tasksByID(after) {
+		task := task
+		previous, ok := old[id]
+		delete(old, id)
+		if !ok {
+			changes = append(changes, Change{After: &task})
+		} else if !reflect.DeepEqual(previous, task) {
+			changes = append(changes, Change{Before: &previous, After: &task})
+		}
+	}
+	for _, task := range old {
+		task := task
+		changes = append(changes, Change{Before: &task})
+	}
+	slices.SortFunc(changes, func(a, b Change) int { return cmp.Compare(a.id(), b.id()) })
+	return changes
+}
+
+// ApplyChanges replaces each Before task with its After. A task that exists
+// when Before is nil, is missing when it is not, or differs from Before means
+// the tasks changed since the operation was recorded, and nothing is applied.
+func ApplyChanges(list TaskList, changes []Change) (TaskList, error) {
+	current := tasksByID(list)
+	for _, change := range changes {
+		task, ok := current[change.id()]
+		if ok != (change.Before != nil) || (ok && !sameTask(task, *change.Before)) {
+			return list, fmt.Errorf("task id=%d has changed since", change.id())
+		}
+		if change.After == nil {
+			delete(current, change.id())
+		} else {
+			current[change.id()] = *change.After
+		}
+	}
+	updated := TaskList{NextID: list.NextID, Tasks: []Task{}}
+	for _, task := range current {
+		if task.ArchivedAt != nil {
+			updated.Archived = append(updated.Archived, task)
+		} else {
+			updated.Tasks = append(updated.Tasks, task)
+		}
+	}
+	byID := func(a, b Task) int { return cmp.Compare(a.ID, b.ID) }
+	slices.SortFunc(updated.Tasks, byID)
+	slices.SortFunc(updated.Archived, byID)
+	updated = updated.Normalize()
+	if err := Validate(updated); err != nil {
+		return list, fmt.Errorf("the result would be invalid: %w", err)
+	}
+	return updated, nil
+}
+
+func tasksByID(list TaskList) map[int]Task {
+	tasks := make(map[int]Task, len(list.Tasks)+len(list.Archived))
+	for _, task := range append(slices.Clone(list.Tasks), list.Archived...) {
+		tasks[task.ID] = task
+	}
+	return tasks
+}
+
+// sameTask compares the tasks field by field, times by the instant they name
+// and nil slices as empty ones, as the journal and the stores do not keep
+// locations or tell nil and empty apart.
+func sameTask(a, b Task) bool {
+	return a.ID == b.ID &&
+		a.Description == b.Description &&
+		a.Status == b.Status &&
+		sameTime(a.Due, b.Due) &&
+		sameTime(a.Scheduled, b.Scheduled) &&
+		a.Priority == b.Priority &&
+		reflect.DeepEqual(a.Estimate, b.Estimate) &&
+		a.Project == b.Project &&
+		slices.Equal(a.Tags, b.Tags) &&
+		(a.Parent == nil) == (b.Parent == nil) && (a.Parent == nil || *a.Parent == *b.Parent) &&
+		slices.Equal(a.DependsOn, b.DependsOn) &&
+		(a.Recurrence == nil) == (b.Recurrence == nil) && (a.Recurrence == nil || a.Recurrence.String() == b.Recurrence.String()) &&
+		slices.EqualFunc(a.Annotations, b.Annotations, func(x, y Annotation) bool { return x.Time.Equal(y.Time) && x.Text == y.Text }) &&
+		a.Notes == b.Notes &&
+		slices.EqualFunc(a.Intervals, b.Intervals, func(x, y Interval) bool { return x.Start.Equal(y.Start) && sameTime(x.End, y.End) }) &&
+		sameTime(a.CreatedAt, b.CreatedAt) &&
+		sameTime(a.UpdatedAt, b.UpdatedAt) &&
+		sameTime(a.CompletedAt, b.CompletedAt) &&
+		sameTime(a.ArchivedAt, b.ArchivedAt) &&
+		a.UUID == b.UUID
+}
+
+func sameTime(a, b *time.Time) bool {
+	return a == b || (a != nil && b != nil && a.Equal(*b))
+}
@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	Now = func() time.Time { return time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC) }
+	defer func() { Now = time.Now }()
+	journal := Journal{}
+	record := func(list TaskList, updated TaskList, command string) TaskList {
+		journal = journal.Record(Operation{Time: Now(), Command: command, Changes: Diff(list, updated)})
+		return updated
+	}
+	list := NewTaskList([]Task{{ID: 0, Description: "Design", Status: StatusTodo}}, 0)
+	list = record(list, Add(list, Task{Description: "Build"}), "add")
+	completed, err := Complete(TaskList{NextID: list.NextID, Tasks: slices.Clone(list.Tasks)}, 0)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	list = record(list, completed, "complete")
+	remaining, _ := DeleteMany(list.Tasks, []int{1}, ChildrenOrphan)
+	list = record(list, TaskList{NextID: list.NextID, Tasks: remaining}, "delete")
+	if summary := journal.Operations[2].Summary(); summary != "1 removed" {
+		t.Errorf("Test failed: summary %q, expected \"1 removed\"", summary)
+	}
+
+	list, journal, undone, err := journal.Undo(list, 2)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if len(undone) != 2 || undone[0].Command != "delete" || undone[1].Command != "complete" {
+		t.Errorf("Test failed: undid %+v", undone)
+	}
+	if ids := taskIDs(list.Tasks); !slices.Equal(ids, []int{0, 1}) || list.Tasks[0].Status != StatusTodo || list.NextID != 2 {
+		t.Errorf("Test failed: unexpected tasks after undo %+v", list)
+	}
+
+	list, journal, redone, err := journal.Redo(list)
+	if err != nil || redone.Command != "complete" || list.Tasks[0].Status != StatusDone {
+		t.Errorf("Test failed: redid %q (%v), tasks %+v", redone.Command, err, list.Tasks)
+	}
+	list = record(list, Add(list, Task{Description: "Ship"}), "add")
+	if len(journal.Operations) != 3 {
+		t.Errorf("Test failed: undone operations kept after a new one: %+v", journal.Operations)
+	}
+	if _, _, _, err := journal.Redo(list); err == nil {
+		t.Error("Test failed: redid a dropped operation")
+	}
+	if _, _, _, err := journal.Undo(list, 4); err == nil {
+		t.Error("Test failed: undid more operations than recorded")
+	}
+
+	// an edit the journal missed must not be overwritten by an undo
+	edited := TaskList{NextID: list.NextID, Tasks: slices.Clone(list.Tasks)}
+	edited.Tasks[2].Notes = "written after the add"
+	if _, _, _, err := journal.Undo(edited, 1); err == nil {
+		t.Error("Test failed: undid an operation over a change that was not recorded")
+	}
+
+	// the task added by the last operation is gone, so it cannot be undone
+	list.Tasks = list.Tasks[:2]
+	if _, _, _, err := journal.Undo(list, 1); err == nil {
+		t.Error("Test failed: undid an operation on tasks changed since")
+	}
+
+	// times in another location and empty slices as loaded back from a file are the same task
+	created := time.Date(2026, 10, 19, 11, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	before := Task{ID: 0, Description: "Design", Status: StatusTodo, CreatedAt: &created}
+	loaded := before
+	loaded.CreatedAt, loaded.Tags = new(time.Time), []string{}
+	*loaded.CreatedAt = created.UTC()
+	changed := before
+	changed.Status = StatusDone
+	applied, err := ApplyChanges(NewTaskList([]Task{loaded}, 0), []Change{{Before: &before, After: &changed}})
+	if err != nil || applied.Tasks[0].Status != StatusDone {
+		t.Errorf("Test failed: got %+v (%v), expected the task done", applied.Tasks, err)
+	}
+}
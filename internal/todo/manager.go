@@ -2,36 +2,99 @@ package todo
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/vladiakimenko/go_project_planner/internal/logging"
 )
 
 type TaskStateFilter string
 
+// FilterPending covers every status that is not closed; the other filters
+// about pending tasks follow it.
 const (
-	FilterAll     TaskStateFilter = "all"
-	FilterDone    TaskStateFilter = "done"
-	FilterPending TaskStateFilter = "pending"
+	FilterAll       TaskStateFilter = "all"
+	FilterDone      TaskStateFilter = "done"
+	FilterPending   TaskStateFilter = "pending"
+	FilterCancelled TaskStateFilter = "cancelled"
+	FilterOverdue   TaskStateFilter = "overdue"
+	FilterToday     TaskStateFilter = "today"
+	FilterUpcoming  TaskStateFilter = "upcoming"
+	FilterReady     TaskStateFilter = "ready"
+	FilterBlocked   TaskStateFilter = "blocked"
 )
 
 var FilterConditionsMap = map[TaskStateFilter]func(Task) bool{
-	FilterAll:     func(t Task) bool { return true },
-	FilterDone:    func(t Task) bool { return t.Done },
-	FilterPending: func(t Task) bool { return !t.Done },
+	FilterAll:       func(t Task) bool { return true },
+	FilterDone:      func(t Task) bool { return t.Status == StatusDone },
+	FilterPending:   func(t Task) bool { return !t.Status.Closed() },
+	FilterCancelled: func(t Task) bool { return t.Status == StatusCancelled },
+	FilterOverdue: func(t Task) bool {
+		return !t.Status.Closed() && t.Due != nil && t.Due.Before(StartOfDay(Now()))
+	},
+	FilterToday: func(t Task) bool {
+		now := Now()
+		dueToday := t.Due != nil && SameDay(now, *t.Due)
+		scheduledByToday := t.Scheduled != nil && t.Scheduled.Before(StartOfDay(now).AddDate(0, 0, 1))
+		return !t.Status.Closed() && (dueToday || scheduledByToday)
+	},
+	FilterUpcoming: func(t Task) bool {
+		tomorrow := StartOfDay(Now()).AddDate(0, 0, 1)
+		return !t.Status.Closed() && t.Due != nil && !t.Due.Before(tomorrow) && t.Due.Before(tomorrow.AddDate(0, 0, UpcomingDays))
+	},
 }
 
-func Add(tasks []Task, desc string) []Task {
-	return append(tasks, Task{
-		ID:          len(tasks),
-		Description: desc,
-		Done:        false,
-	})
+// DependencyFilterConditionsMap holds the filters that judge a task by the
+// tasks it depends on.
+var DependencyFilterConditionsMap = map[TaskStateFilter]func(Graph, Task) bool{
+	FilterReady:   func(g Graph, t Task) bool { return g.Ready(t) },
+	FilterBlocked: func(g Graph, t Task) bool { return !t.Status.Closed() && len(g.Blockers(t)) > 0 },
 }
 
-func List(tasks []Task, filter string) []Task {
+func Filters() []TaskStateFilter {
+	return []TaskStateFilter{
+		FilterAll, FilterDone, FilterPending, FilterCancelled, FilterOverdue, FilterToday, FilterUpcoming,
+		FilterReady, FilterBlocked,
+	}
+}
+
+// FilterCondition returns the condition of filter, evaluating dependency
+// filters against tasks.
+func FilterCondition(filter TaskStateFilter, tasks []Task) (func(Task) bool, bool) {
+	if condition, ok := FilterConditionsMap[filter]; ok {
+		return condition, true
+	}
+	if condition, ok := DependencyFilterConditionsMap[filter]; ok {
+		graph := NewGraph(tasks)
+		return func(t Task) bool { return condition(graph, t) }, true
+	}
+	return nil, false
+}
+
+// Add appends task under the next free id; the id and timestamps it carries
+// are ignored.
+func Add(list TaskList, task Task) TaskList {
+	list = list.Normalize()
+	now := Now()
+	task.ID = list.NextID
+	task.CreatedAt = &now
+	task.UpdatedAt = &now
+	task.CompletedAt = nil
+	task.ArchivedAt = nil
+	if task.Status == "" {
+		task.Status = ActiveWorkflow.Initial()
+	}
+	if task.Status == StatusDone {
+		task.CompletedAt = &now
+	}
+	list.Tasks = append(list.Tasks, task)
+	list.NextID++
+	return list
+}
+
+func List(tasks []Task, filter string, sorts ...SortKey) []Task {
 	result := []Task{}
-	filterType := TaskStateFilter(filter)
-	filterFunc, ok := FilterConditionsMap[filterType]
+	filterFunc, ok := FilterCondition(TaskStateFilter(filter), tasks)
 	if !ok {
 		filterFunc = FilterConditionsMap[FilterAll]
 	}
@@ -40,13 +103,51 @@ func List(tasks []Task, filter string) []Task {
 			result = append(result, item)
 		}
 	}
+	Sort(result, sorts...)
 	return result
 }
 
-func Complete(tasks []Task, id int) ([]Task, error) {
+// Complete marks task id as done.
+func Complete(list TaskList, id int) (TaskList, error) {
+	return SetStatus(list, id, StatusDone)
+}
+
+// SetStatus moves task id to status if the active workflow allows it. A task
+// cannot be closed while it has open subtasks. Completing a recurring task
+// appends its next occurrence under a new id.
+func SetStatus(list TaskList, id int, status Status) (TaskList, error) {
+	for i, task := range list.Tasks {
+		if task.ID == id {
+			if err := checkStatusChange(list.Tasks, task, status, nil); err != nil {
+				logging.Logger.Error("Could not change the status of a task", "id", id, "status", status, "error", err.Error())
+				return TaskList{Tasks: []Task{}}, fmt.Errorf("task id=%d: %w", id, err)
+			}
+			list, _ = setStatusAt(list, i, status, Now())
+			return list, nil
+		}
+	}
+	logging.Logger.Error("Could not find a task with specified id", "id", id)
+	return TaskList{Tasks: []Task{}}, fmt.Errorf("task with requested id=%d is missing", id)
+}
+
+func Update(tasks []Task, id int, patch Patch) ([]Task, error) {
 	for i, task := range tasks {
 		if task.ID == id {
-			tasks[i].Done = true
+			updatedTask, err := patch.Apply(task)
+			if err == nil && updatedTask.Parent != nil && !sameParent(task, updatedTask) {
+				err = ValidateParent(tasks, id, *updatedTask.Parent)
+			}
+			if err == nil && !slices.Equal(task.DependsOn, updatedTask.DependsOn) {
+				err = ValidateDependencies(tasks, id, updatedTask.DependsOn)
+			}
+			if err == nil {
+				err = checkStatusChange(tasks, task, updatedTask.Status, nil)
+			}
+			if err != nil {
+				logging.Logger.Error("Could not apply the changes to a task", "id", id, "error", err.Error())
+				return []Task{}, fmt.Errorf("failed to update task id=%d: %w", id, err)
+			}
+			tasks[i] = touch(task, updatedTask, Now())
 			return tasks, nil
 		}
 	}
@@ -54,13 +155,82 @@ func Complete(tasks []Task, id int) ([]Task, error) {
 	return []Task{}, fmt.Errorf("task with requested id=%d is missing", id)
 }
 
-func Delete(tasks []Task, id int) ([]Task, error) {
-	for i, task := range tasks {
+// Delete removes task id; policy decides whether its subtasks are deleted
+// along with it or become top-level tasks. Dependencies on it are dropped.
+func Delete(tasks []Task, id int, policy ChildPolicy) ([]Task, error) {
+	for _, task := range tasks {
 		if task.ID == id {
-			tasks = append(tasks[:i], tasks[i+1:]...)
-			return tasks, nil
+			remaining, _ := DeleteMany(tasks, []int{id}, policy)
+			return remaining, nil
 		}
 	}
 	logging.Logger.Error("Could not find a task with specified id", "id", id)
 	return []Task{}, fmt.Errorf("task with requested id=%d is missing", id)
 }
+
+// checkStatusChange reports why task cannot move to status. Open subtasks
+// for which ignore returns true do not count.
+func checkStatusChange(tasks []Task, task Task, status Status, ignore func(id int) bool) error {
+	if task.Status == status {
+		return nil
+	}
+	if !ActiveWorkflow.Allows(task.Status, status) {
+		return fmt.Errorf("%w: %s -> %s", ErrTransition, task.Status, status)
+	}
+	if !status.Closed() {
+		return nil
+	}
+	open := slices.DeleteFunc(OpenSubtasks(tasks, task.ID), func(id int) bool { return ignore != nil && ignore(id) })
+	if len(open) > 0 {
+		return fmt.Errorf("%w %v", ErrOpenSubtasks, open)
+	}
+	return nil
+}
+
+// setStatusAt moves the task at index i to status and returns the id of the
+// next occurrence it spawned, or -1.
+func setStatusAt(list TaskList, i int, status Status, now time.Time) (TaskList, int) {
+	task := list.Tasks[i]
+	if task.Status == status {
+		return list, -1
+	}
+	list.Tasks[i] = touch(task, withStatus(task, status), now)
+	if status != StatusDone || task.Recurrence == nil {
+		return list, -1
+	}
+	next := nextOccurrence(task, now)
+	list = list.Normalize()
+	next.ID = list.NextID
+	next.CreatedAt = &now
+	next.UpdatedAt = &now
+	list.Tasks = append(list.Tasks, next)
+	list.NextID++
+	logging.Logger.Debug("Spawned the next occurrence of a recurring task", "id", task.ID, "next_id", next.ID)
+	return list, next.ID
+}
+
+func withStatus(task Task, status Status) Task {
+	task.Status = status
+	return task
+}
+
+func sameParent(a Task, b Task) bool {
+	return (a.Parent == nil) == (b.Parent == nil) && (a.Parent == nil || *a.Parent == *b.Parent)
+}
+
+// touch stamps an edited task, keeping CompletedAt in line with the status:
+// completing it again keeps the original completion time. Closing a task
+// stops its timer.
+func touch(before Task, after Task, now time.Time) Task {
+	after.UpdatedAt = &now
+	if after.Status.Closed() {
+		after.Intervals = stopTimer(after.Intervals, now)
+	}
+	switch {
+	case after.Status == StatusDone && before.Status != StatusDone:
+		after.CompletedAt = &now
+	case after.Status != StatusDone:
+		after.CompletedAt = nil
+	}
+	return after
+}
@@ -2,28 +2,69 @@ package todo
 
 import (
 	"testing"
+	"time"
 )
 
 var testTasks = []Task{
-	{ID: 0, Description: "Test task A", Done: false},
-	{ID: 1, Description: "Test task B", Done: true},
-	{ID: 2, Description: "Test task C", Done: false},
+	{ID: 0, Description: "Test task A", Status: StatusTodo},
+	{ID: 1, Description: "Test task B", Status: StatusDone},
+	{ID: 2, Description: "Test task C", Status: StatusTodo},
 }
 
 func TestAdd(t *testing.T) {
 	t.Run("add", func(t *testing.T) {
-		tasks := append([]Task{}, testTasks...) // copy slice
-		originalLength := len(tasks)
-		tasks = Add(tasks, "Test Task X")
+		list := NewTaskList(append([]Task{}, testTasks...), 0) // copy slice
+		originalLength := len(list.Tasks)
+		list = Add(list, Task{Description: "Test Task X"})
+		tasks := list.Tasks
 
 		if len(tasks) != originalLength+1 {
 			t.Fatalf("Test failed: incorrect number of tasks: %d", len(tasks))
 		}
 
-		if tasks[len(tasks)-1].Done != false {
-			t.Errorf("Test failed: Incorrect initial 'Done' value: %+v", tasks[0])
+		if tasks[len(tasks)-1].Status != StatusTodo {
+			t.Errorf("Test failed: Incorrect initial status: %+v", tasks[len(tasks)-1])
 		}
 	})
+
+	t.Run("ids are not reused after delete", func(t *testing.T) {
+		list := NewTaskList(append([]Task{}, testTasks...), 0)
+		updatedTasks, err := Delete(list.Tasks, 2, ChildrenOrphan)
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		list.Tasks = updatedTasks
+		list = Add(list, Task{Description: "Test Task X"})
+		if got := list.Tasks[len(list.Tasks)-1].ID; got != 3 {
+			t.Errorf("Test failed: expected the new task to get id 3, got %d", got)
+		}
+		if list.NextID != 4 {
+			t.Errorf("Test failed: expected NextID 4, got %d", list.NextID)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		list          TaskList
+		errorExpected bool
+	}{
+		{"valid list", NewTaskList(append([]Task{}, testTasks...), 0), false},
+		{"duplicate id", TaskList{NextID: 3, Tasks: []Task{{ID: 1}, {ID: 1}}}, true},
+		{"negative id", TaskList{NextID: 3, Tasks: []Task{{ID: -1}}}, true},
+		{"id above the high-water mark", TaskList{NextID: 1, Tasks: []Task{{ID: 2}}}, true},
+		{"missing parent", TaskList{NextID: 3, Tasks: []Task{{ID: 1, Parent: intPtr(2)}}}, true},
+		{"parent cycle", TaskList{NextID: 3, Tasks: []Task{{ID: 1, Parent: intPtr(2)}, {ID: 2, Parent: intPtr(1)}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.list)
+			if (err != nil) != tt.errorExpected {
+				t.Errorf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+		})
+	}
 }
 
 func TestList(t *testing.T) {
@@ -46,6 +87,41 @@ func TestList(t *testing.T) {
 		})
 	}
 
+	t.Run("date filters", func(t *testing.T) {
+		Now = func() time.Time { return time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC) }
+		defer func() { Now = time.Now }()
+		day := func(d int) *time.Time {
+			date := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+			return &date
+		}
+		tasks := []Task{
+			{ID: 0, Description: "overdue", Due: day(17)},
+			{ID: 1, Description: "overdue but done", Status: StatusDone, Due: day(10)},
+			{ID: 2, Description: "due today", Due: day(18)},
+			{ID: 3, Description: "scheduled earlier", Scheduled: day(16), Due: day(30)},
+			{ID: 4, Description: "due tomorrow", Due: day(19)},
+			{ID: 5, Description: "due in a week", Due: day(25)},
+			{ID: 6, Description: "no dates"},
+		}
+		expected := map[TaskStateFilter][]int{
+			FilterOverdue:  {0},
+			FilterToday:    {2, 3},
+			FilterUpcoming: {4, 5},
+		}
+		for filter, ids := range expected {
+			got := List(tasks, string(filter))
+			if len(got) != len(ids) {
+				t.Errorf("Test failed: filter %s returned %v, expected ids %v", filter, got, ids)
+				continue
+			}
+			for i := range got {
+				if got[i].ID != ids[i] {
+					t.Errorf("Test failed: filter %s returned %v, expected ids %v", filter, got, ids)
+				}
+			}
+		}
+	})
+
 	t.Run("unknown filter defaults to all", func(t *testing.T) {
 		tasks := append([]Task{}, testTasks...)
 		got := List(tasks, "unknown")
@@ -65,9 +141,10 @@ func TestComplete(t *testing.T) {
 		{"complete non-existent task", 999, true},
 	}
 	for _, tt := range tests {
-		tasks := append([]Task{}, testTasks...)
+		list := NewTaskList(append([]Task{}, testTasks...), 0)
 		t.Run(tt.name, func(t *testing.T) {
-			updatedTasks, err := Complete(tasks, tt.id)
+			updatedList, err := Complete(list, tt.id)
+			updatedTasks := updatedList.Tasks
 
 			if tt.errorExpected {
 				if err == nil {
@@ -81,7 +158,7 @@ func TestComplete(t *testing.T) {
 					t.Errorf("Test failed: Unexpected error: %v", err)
 				}
 				// testTasks indices match the ID's
-				if !updatedTasks[tt.id].Done {
+				if updatedTasks[tt.id].Status != StatusDone {
 					t.Errorf("Task %d was not marked as done", tt.id)
 				}
 			}
@@ -101,7 +178,7 @@ func TestDelete(t *testing.T) {
 	for _, tt := range tests {
 		tasks := append([]Task{}, testTasks...)
 		t.Run(tt.name, func(t *testing.T) {
-			updatedTasks, err := Delete(tasks, tt.id)
+			updatedTasks, err := Delete(tasks, tt.id, ChildrenOrphan)
 			originalLength := len(tasks)
 			if tt.errorExpected {
 				if err == nil {
@@ -126,3 +203,85 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdate(t *testing.T) {
+	newDesc := "Edited task"
+	reopen := StatusTodo
+	cancel := StatusCancelled
+	tests := []struct {
+		name          string
+		id            int
+		patch         Patch
+		errorExpected bool
+	}{
+		{"update description", 0, Patch{Description: &newDesc}, false},
+		{"reopen done task", 1, Patch{Status: &reopen}, false},
+		{"cancel pending task", 0, Patch{Status: &cancel}, false},
+		{"empty description", 0, Patch{Description: new(string)}, true},
+		{"update non-existent task", 999, Patch{Description: &newDesc}, true},
+	}
+	for _, tt := range tests {
+		tasks := append([]Task{}, testTasks...)
+		t.Run(tt.name, func(t *testing.T) {
+			updatedTasks, err := Update(tasks, tt.id, tt.patch)
+			if tt.errorExpected {
+				if err == nil {
+					t.Error("Test failed: Expected an error but didn't get one")
+				}
+				if len(updatedTasks) != 0 {
+					t.Error("Test failed: Expected an empty slice along with an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			// testTasks indices match the ID's
+			if tt.patch.Description != nil && updatedTasks[tt.id].Description != *tt.patch.Description {
+				t.Errorf("Test failed: description not updated: %v", updatedTasks[tt.id])
+			}
+			if tt.patch.Status != nil && updatedTasks[tt.id].Status != *tt.patch.Status {
+				t.Errorf("Test failed: status not updated: %v", updatedTasks[tt.id])
+			}
+		})
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	clock := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return clock }
+	defer func() { Now = time.Now }()
+
+	list := Add(TaskList{}, Task{Description: "Task"})
+	created := list.Tasks[0]
+	if created.CreatedAt == nil || !created.CreatedAt.Equal(clock) || created.UpdatedAt == nil || created.CompletedAt != nil {
+		t.Fatalf("Test failed: unexpected timestamps after add: %+v", created)
+	}
+
+	clock = clock.Add(time.Hour)
+	list, err := Complete(list, 0)
+	tasks := list.Tasks
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	completedAt := clock
+	if tasks[0].CompletedAt == nil || !tasks[0].CompletedAt.Equal(completedAt) || !tasks[0].UpdatedAt.Equal(completedAt) {
+		t.Errorf("Test failed: unexpected timestamps after complete: %+v", tasks[0])
+	}
+
+	clock = clock.Add(time.Hour)
+	list, _ = Complete(list, 0)
+	tasks = list.Tasks
+	if !tasks[0].CompletedAt.Equal(completedAt) {
+		t.Errorf("Test failed: completing twice moved CompletedAt to %v", tasks[0].CompletedAt)
+	}
+
+	reopen := StatusTodo
+	tasks, err = Update(tasks, 0, Patch{Status: &reopen})
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if tasks[0].CompletedAt != nil || !tasks[0].UpdatedAt.Equal(clock) || !tasks[0].CreatedAt.Equal(created.CreatedAt.UTC()) {
+		t.Errorf("Test failed: unexpected timestamps after reopening: %+v", tasks[0])
+	}
+}
@@ -0,0 +1,129 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+// AnnotationLayout is how annotation times are shown to people.
+const AnnotationLayout string = "2006-01-02 15:04"
+
+// Annotation is a timestamped remark, such as "waiting on vendor", added to a
+// task over its life.
+type Annotation struct {
+	Time time.Time `yaml:"Time"`
+	Text string    `yaml:"Text"`
+}
+
+func (a Annotation) String() string {
+	return a.Time.Format(AnnotationLayout) + " " + a.Text
+}
+
+// Annotate appends an annotation stamped with the current time to task id.
+func Annotate(tasks []Task, id int, text string) ([]Task, error) {
+	text = NormalizeNotes(text)
+	if strings.TrimSpace(text) == "" {
+		return []Task{}, errors.New("annotation cannot be empty")
+	}
+	for i, task := range tasks {
+		if task.ID == id {
+			now := Now()
+			annotations := append([]Annotation{}, task.Annotations...)
+			tasks[i].Annotations = append(annotations, Annotation{Time: now, Text: text})
+			tasks[i].UpdatedAt = &now
+			return tasks, nil
+		}
+	}
+	logging.Logger.Error("Could not find a task with specified id", "id", id)
+	return []Task{}, fmt.Errorf("task with requested id=%d is missing", id)
+}
+
+// NormalizeNotes converts Windows and old Mac line endings to "\n", the only
+// line break stored in notes and annotations.
+func NormalizeNotes(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// Detail renders every field of the task over several lines, including the
+// annotations and notes Task.String leaves out.
+func (t Task) Detail() string {
+	var b strings.Builder
+	line := func(name string, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", name+":", value)
+		}
+	}
+	optionalTime := func(value *time.Time, layout string) string {
+		if value == nil {
+			return ""
+		}
+		return value.Local().Format(layout)
+	}
+	line("ID", fmt.Sprint(t.ID))
+	line("Description", t.Description)
+	line("Status", string(t.Status))
+	if t.Parent != nil {
+		line("Parent", fmt.Sprint(*t.Parent))
+	}
+	if len(t.DependsOn) > 0 {
+		line("Depends on", strings.ReplaceAll(strings.Trim(fmt.Sprint(t.DependsOn), "[]"), " ", ", "))
+	}
+	line("Project", t.Project)
+	if len(t.Tags) > 0 {
+		line("Tags", TagPrefix+strings.Join(t.Tags, " "+TagPrefix))
+	}
+	if t.Priority != PriorityNone {
+		line("Priority", t.Priority.String())
+	}
+	if t.Estimate != nil {
+		line("Estimate", t.Estimate.String())
+	}
+	line("Due", optionalTime(t.Due, DateLayout))
+	line("Scheduled", optionalTime(t.Scheduled, DateLayout))
+	if t.Recurrence != nil {
+		line("Repeats", t.Recurrence.String())
+	}
+	if len(t.Intervals) > 0 {
+		logged := FormatDuration(Logged(t, nil, nil, Now()))
+		if t.timerRunning() {
+			logged += " (timer running)"
+		}
+		line("Logged", logged)
+	}
+	line("Created", optionalTime(t.CreatedAt, AnnotationLayout))
+	line("Updated", optionalTime(t.UpdatedAt, AnnotationLayout))
+	line("Completed", optionalTime(t.CompletedAt, AnnotationLayout))
+	line("Archived", optionalTime(t.ArchivedAt, AnnotationLayout))
+	if len(t.Annotations) > 0 {
+		b.WriteString("Annotations:\n")
+		for _, annotation := range t.Annotations {
+			annotation.Time = annotation.Time.Local()
+			b.WriteString(indent(annotation.String()))
+		}
+	}
+	if len(t.Intervals) > 0 {
+		b.WriteString("Time log:\n")
+		for _, interval := range t.Intervals {
+			interval.Start = interval.Start.Local()
+			if interval.End != nil {
+				end := interval.End.Local()
+				interval.End = &end
+			}
+			b.WriteString(indent(fmt.Sprintf("%s (%s)", interval, FormatDuration(interval.Duration(Now())))))
+		}
+	}
+	if t.Notes != "" {
+		b.WriteString("Notes:\n")
+		b.WriteString(indent(t.Notes))
+	}
+	return b.String()
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	return "  " + strings.Join(lines, "\n  ") + "\n"
+}
@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	Now = func() time.Time { return now }
+	defer func() { Now = time.Now }()
+
+	tasks, err := Annotate(append([]Task{}, testTasks...), 1, "waiting on vendor\r\nuntil friday")
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	annotations := tasks[1].Annotations
+	if len(annotations) != 1 || annotations[0].Text != "waiting on vendor\nuntil friday" || !annotations[0].Time.Equal(now) {
+		t.Errorf("Test failed: unexpected annotations %v", annotations)
+	}
+	if tasks[1].UpdatedAt == nil || !tasks[1].UpdatedAt.Equal(now) {
+		t.Errorf("Test failed: annotating did not update the task: %v", tasks[1].UpdatedAt)
+	}
+	if len(testTasks[1].Annotations) != 0 {
+		t.Error("Test failed: the annotation leaked into the original task")
+	}
+
+	if _, err := Annotate(append([]Task{}, testTasks...), 9, "text"); err == nil {
+		t.Error("Test failed: annotated a missing task")
+	}
+	if _, err := Annotate(append([]Task{}, testTasks...), 1, " \n"); err == nil {
+		t.Error("Test failed: accepted an empty annotation")
+	}
+}
+
+func TestDetail(t *testing.T) {
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	task := Task{
+		ID: 4, Description: "Deploy", Status: StatusTodo, Project: "work", Tags: []string{"ops", "urgent"}, Due: &due, DependsOn: []int{1, 2},
+		Annotations: []Annotation{{Time: time.Date(2026, 10, 18, 9, 30, 0, 0, time.Local), Text: "waiting on vendor"}},
+		Notes:       "# Steps\n- backup\n",
+	}
+	detail := task.Detail()
+	for _, expected := range []string{
+		"ID:          4\n",
+		"Status:      todo\n",
+		"Tags:        +ops +urgent\n",
+		"Depends on:  1, 2\n",
+		"Due:         2026-10-20\n",
+		"Annotations:\n  2026-10-18 09:30 waiting on vendor\n",
+		"Notes:\n  # Steps\n  - backup\n",
+	} {
+		if !strings.Contains(detail, expected) {
+			t.Errorf("Test failed: %q is missing from\n%s", expected, detail)
+		}
+	}
+	if strings.Contains(detail, "Priority") {
+		t.Errorf("Test failed: unset fields are shown:\n%s", detail)
+	}
+}
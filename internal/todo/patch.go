@@ -0,0 +1,110 @@
+package todo
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Patch describes a partial update of a task: nil fields are left untouched.
+// Dates are cleared by pointing at the zero time and Parent by pointing at a
+// negative id.
+type Patch struct {
+	Description *string
+	Status      *Status
+	Due         *time.Time
+	Scheduled   *time.Time
+	Priority    *Priority
+	Project     *string
+	Parent      *int
+	// Recurrence is cleared by pointing at a rule without a frequency and
+	// Estimate by pointing at a zero estimate.
+	Recurrence *Recurrence
+	Estimate   *Estimate
+	Notes      *string
+	AddTags    []string
+	RemoveTags []string
+	// AddDependencies and RemoveDependencies hold task ids.
+	AddDependencies    []int
+	RemoveDependencies []int
+}
+
+func (p Patch) Apply(task Task) (Task, error) {
+	if p.Description != nil {
+		if *p.Description == "" {
+			return task, errors.New("description cannot be empty")
+		}
+		task.Description = *p.Description
+	}
+	if p.Status != nil {
+		task.Status = *p.Status
+	}
+	if p.Due != nil {
+		task.Due = optionalTime(*p.Due)
+	}
+	if p.Scheduled != nil {
+		task.Scheduled = optionalTime(*p.Scheduled)
+	}
+	if p.Priority != nil {
+		task.Priority = *p.Priority
+	}
+	if p.Estimate != nil {
+		task.Estimate = nil
+		if p.Estimate.Amount > 0 {
+			estimate := *p.Estimate
+			task.Estimate = &estimate
+		}
+	}
+	if p.Project != nil {
+		if err := ValidateProject(*p.Project); err != nil {
+			return task, err
+		}
+		task.Project = *p.Project
+	}
+	if p.Parent != nil {
+		task.Parent = nil
+		if *p.Parent >= 0 {
+			parent := *p.Parent
+			task.Parent = &parent
+		}
+	}
+	if p.Recurrence != nil {
+		task.Recurrence = nil
+		if p.Recurrence.Frequency != "" {
+			rule := *p.Recurrence
+			task.Recurrence = &rule
+		}
+	}
+	if p.Notes != nil {
+		task.Notes = NormalizeNotes(*p.Notes)
+	}
+	if len(p.AddTags) > 0 || len(p.RemoveTags) > 0 {
+		removed, err := NormalizeTags(p.RemoveTags)
+		if err != nil {
+			return task, err
+		}
+		tags, err := NormalizeTags(append(slices.Clone(task.Tags), p.AddTags...))
+		if err != nil {
+			return task, err
+		}
+		tags = slices.DeleteFunc(tags, func(tag string) bool { return slices.Contains(removed, tag) })
+		task.Tags = nil
+		if len(tags) > 0 {
+			task.Tags = tags
+		}
+	}
+	if len(p.AddDependencies) > 0 || len(p.RemoveDependencies) > 0 {
+		dependencies := normalizeIDs(append(slices.Clone(task.DependsOn), p.AddDependencies...))
+		task.DependsOn = normalizeIDs(slices.DeleteFunc(dependencies, func(id int) bool {
+			return slices.Contains(p.RemoveDependencies, id)
+		}))
+	}
+	return task, nil
+}
+
+func optionalTime(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	return &value
+}
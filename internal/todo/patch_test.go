@@ -0,0 +1,54 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPatchApply(t *testing.T) {
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	task := Task{ID: 3, Description: "Task", Due: &due, Priority: PriorityLow, Project: "work", Tags: []string{"a", "b"}}
+	high := PriorityHigh
+	noProject := ""
+	badProject := "work..x"
+
+	t.Run("untouched fields are kept", func(t *testing.T) {
+		got, err := Patch{Priority: &high}.Apply(task)
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		if got.Priority != PriorityHigh || got.Description != "Task" || got.Due == nil || got.Project != "work" {
+			t.Errorf("Test failed: unexpected result %v", got)
+		}
+	})
+
+	t.Run("zero time and empty project clear the fields", func(t *testing.T) {
+		got, err := Patch{Due: &time.Time{}, Project: &noProject}.Apply(task)
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		if got.Due != nil || got.Project != "" {
+			t.Errorf("Test failed: fields were not cleared: %v", got)
+		}
+	})
+
+	t.Run("tags are added and removed", func(t *testing.T) {
+		got, err := Patch{AddTags: []string{"+c", "a"}, RemoveTags: []string{"b"}}.Apply(task)
+		if err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		if !slices.Equal(got.Tags, []string{"a", "c"}) {
+			t.Errorf("Test failed: got tags %v", got.Tags)
+		}
+		if !slices.Equal(task.Tags, []string{"a", "b"}) {
+			t.Errorf("Test failed: the original task was modified: %v", task.Tags)
+		}
+	})
+
+	t.Run("invalid project", func(t *testing.T) {
+		if _, err := (Patch{Project: &badProject}).Apply(task); err == nil {
+			t.Error("Test failed: Expected an error but didn't get one")
+		}
+	})
+}
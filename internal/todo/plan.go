@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"math"
+	"time"
+)
+
+// planEpsilon absorbs float rounding when filling a day up to its capacity.
+const planEpsilon = 1e-9
+
+// PlanEntry places a task in a plan: work on it starts on Start and ends on
+// Finish, both days.
+type PlanEntry struct {
+	Task      Task
+	Remaining float64
+	Start     time.Time
+	Finish    time.Time
+}
+
+// Late reports whether the task is planned to finish after its due day.
+func (e PlanEntry) Late() bool {
+	if e.Task.Due == nil {
+		return false
+	}
+	due := e.Task.Due.In(e.Finish.Location())
+	return StartOfDay(e.Finish).After(StartOfDay(due))
+}
+
+// Plan lays out pending work over the days following From, Capacity a day.
+// Unestimated holds the pending tasks left out for lacking an estimate in
+// the unit of the capacity.
+type Plan struct {
+	From        time.Time
+	Capacity    Estimate
+	Entries     []PlanEntry
+	Unestimated []Task
+}
+
+// MakePlan fills the days from from on with the pending tasks, highest
+// priority first, then by due date and id; a task never comes before the
+// tasks it depends on. A task is worked on until its remaining effort is
+// used up, spilling over into the next days when needed.
+func MakePlan(tasks []Task, capacity Estimate, from time.Time) Plan {
+	now := Now()
+	plan := Plan{From: StartOfDay(from), Capacity: capacity, Entries: []PlanEntry{}, Unestimated: []Task{}}
+	pending := List(tasks, string(FilterPending),
+		SortKey{Field: "priority", Descending: true}, SortKey{Field: "due"}, SortKey{Field: "id"}, SortKey{Field: SortTopo},
+	)
+	day, used := plan.From, 0.0
+	for _, task := range pending {
+		remaining, ok := Remaining(task, capacity.Unit, now)
+		if !ok {
+			plan.Unestimated = append(plan.Unestimated, task)
+			continue
+		}
+		if capacity.Amount-used < planEpsilon {
+			day, used = day.AddDate(0, 0, 1), 0
+		}
+		entry := PlanEntry{Task: task, Remaining: remaining, Start: day}
+		if over := remaining - (capacity.Amount - used); over > planEpsilon {
+			// the whole days the task spills over into, the last one partly used
+			days := math.Max(math.Ceil(over/capacity.Amount-planEpsilon), 1)
+			day, used = day.AddDate(0, 0, int(days)), over-(days-1)*capacity.Amount
+		} else {
+			used += remaining
+		}
+		entry.Finish = day
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan
+}
@@ -0,0 +1,119 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      Estimate
+		text          string
+		errorExpected bool
+	}{
+		{"4h", Estimate{4, Hours}, "4h", false},
+		{"1h30m", Estimate{1.5, Hours}, "1h30m", false},
+		{"90m", Estimate{1.5, Hours}, "1h30m", false},
+		{"2.5", Estimate{2.5, Hours}, "2h30m", false},
+		{"5pt", Estimate{5, Points}, "5pt", false},
+		{"3 SP", Estimate{3, Points}, "3pt", false},
+		{"0.5 points", Estimate{0.5, Points}, "0.5pt", false},
+		{"0", Estimate{}, "", true},
+		{"10s", Estimate{}, "", true},
+		{"-2h", Estimate{}, "", true},
+		{"1h30mpt", Estimate{}, "", true},
+		{"soon", Estimate{}, "", true},
+		{"inf", Estimate{}, "", true},
+		{"NaN pt", Estimate{}, "", true},
+		{"1e300", Estimate{}, "", true},
+		{"10001pt", Estimate{}, "", true},
+		{"0.001pt", Estimate{}, "", true},
+		{"10000h", Estimate{10000, Hours}, "10000h", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseEstimate(tt.value)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if got != tt.expected || (err == nil && got.String() != tt.text) {
+				t.Errorf("Test failed: got %v (%s), expected %v (%s)", got, got, tt.expected, tt.text)
+			}
+		})
+	}
+}
+
+func TestMakePlan(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	Now = func() time.Time { return now }
+	defer func() { Now = time.Now }()
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 0, 0, 0, 0, time.Local)
+		return &date
+	}
+	hours := func(amount float64) *Estimate { return &Estimate{amount, Hours} }
+	loggedHour := []Interval{{Start: now.Add(-time.Hour), End: &now}}
+	tasks := []Task{
+		{ID: 0, Description: "Spec", Status: StatusTodo, Priority: PriorityLow, Estimate: hours(3)},
+		{ID: 1, Description: "API", Status: StatusInProgress, Priority: PriorityHigh, Estimate: hours(5), Due: day(19), Intervals: loggedHour},
+		{ID: 2, Description: "Docs", Status: StatusTodo, Priority: PriorityHigh, Estimate: hours(6), Due: day(21)},
+		{ID: 3, Description: "Ideas", Status: StatusTodo, Priority: PriorityHigh},
+		{ID: 4, Description: "Done", Status: StatusDone, Estimate: hours(2)},
+		{ID: 5, Description: "Story", Status: StatusTodo, Estimate: &Estimate{3, Points}},
+		{ID: 6, Description: "Schema", Status: StatusTodo, Priority: PriorityLow, Estimate: hours(2), Due: day(20)},
+		{ID: 7, Description: "Deploy", Status: StatusTodo, Priority: PriorityHigh, Estimate: hours(1), DependsOn: []int{6}},
+	}
+	plan := MakePlan(tasks, Estimate{6, Hours}, now)
+
+	type placement struct {
+		id        int
+		remaining float64
+		start     int
+		finish    int
+		late      bool
+	}
+	expected := []placement{
+		{1, 4, 19, 19, false},
+		{2, 6, 19, 20, false},
+		{6, 2, 20, 20, false},
+		{7, 1, 21, 21, false},
+		{0, 3, 21, 21, false},
+	}
+	if len(plan.Entries) != len(expected) {
+		t.Fatalf("Test failed: got %d entries, expected %d: %+v", len(plan.Entries), len(expected), plan.Entries)
+	}
+	for i, entry := range plan.Entries {
+		want := expected[i]
+		if entry.Task.ID != want.id || entry.Remaining != want.remaining || !entry.Start.Equal(*day(want.start)) ||
+			!entry.Finish.Equal(*day(want.finish)) || entry.Late() != want.late {
+			t.Errorf("Test failed: entry %d is task %d, %v left, %s - %s, late %v; expected %+v",
+				i, entry.Task.ID, entry.Remaining, entry.Start.Format(DateLayout), entry.Finish.Format(DateLayout), entry.Late(), want)
+		}
+	}
+	if ids := taskIDs(plan.Unestimated); !slices.Equal(ids, []int{3, 5}) {
+		t.Errorf("Test failed: unestimated tasks %v, expected [3 5]", ids)
+	}
+
+	tight := MakePlan(tasks, Estimate{2, Hours}, now)
+	late := []int{}
+	for _, entry := range tight.Entries {
+		if entry.Late() {
+			late = append(late, entry.Task.ID)
+		}
+	}
+	if !slices.Equal(late, []int{1, 2, 6}) {
+		t.Errorf("Test failed: late tasks %v, expected [1 2 6]", late)
+	}
+
+	points := MakePlan(tasks, Estimate{2, Points}, now)
+	if len(points.Entries) != 1 || points.Entries[0].Task.ID != 5 || !points.Entries[0].Finish.Equal(*day(20)) {
+		t.Errorf("Test failed: unexpected points plan %+v", points.Entries)
+	}
+
+	long := MakePlan([]Task{{ID: 0, Description: "Rewrite", Status: StatusTodo, Estimate: &Estimate{10000, Points}}}, Estimate{0.01, Points}, now)
+	if finish := day(19).AddDate(0, 0, 999999); len(long.Entries) != 1 || !long.Entries[0].Finish.Equal(finish) {
+		t.Errorf("Test failed: unexpected long plan %+v, expected to finish %s", long.Entries, finish.Format(DateLayout))
+	}
+}
@@ -0,0 +1,53 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Priority ranges from 1 (lowest) to 9 (highest); 0 means none was set.
+type Priority int
+
+const (
+	PriorityNone   Priority = 0
+	PriorityLow    Priority = 1
+	PriorityMedium Priority = 5
+	PriorityHigh   Priority = 9
+)
+
+var priorityAliases = map[string]Priority{
+	"L": PriorityLow,
+	"M": PriorityMedium,
+	"H": PriorityHigh,
+}
+
+// Rank places the priority on a scale of levels, for formats with fewer
+// priorities: 1 is the lowest level and levels the highest, 0 means none.
+// The priorities are split evenly, so with the three levels of the aliases
+// 1-3 rank as L, 4-6 as M and 7-9 as H.
+func (p Priority) Rank(levels int) int {
+	if p == PriorityNone {
+		return 0
+	}
+	return int(p-PriorityLow)*levels/int(PriorityHigh) + 1
+}
+
+func ParsePriority(value string) (Priority, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return PriorityNone, nil
+	}
+	if priority, ok := priorityAliases[value]; ok {
+		return priority, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < int(PriorityNone) || number > int(PriorityHigh) {
+		return PriorityNone, fmt.Errorf("invalid priority %q, expected H, M, L or a number from 0 to 9", value)
+	}
+	return Priority(number), nil
+}
+
+func (p Priority) String() string {
+	return strconv.Itoa(int(p))
+}
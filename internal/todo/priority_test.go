@@ -0,0 +1,57 @@
+package todo
+
+import "testing"
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      Priority
+		errorExpected bool
+	}{
+		{"", PriorityNone, false},
+		{"h", PriorityHigh, false},
+		{"M", PriorityMedium, false},
+		{"L", PriorityLow, false},
+		{"0", PriorityNone, false},
+		{"7", 7, false},
+		{"10", PriorityNone, true},
+		{"-1", PriorityNone, true},
+		{"urgent", PriorityNone, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParsePriority(tt.input)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if got != tt.expected {
+				t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPriorityRank(t *testing.T) {
+	tests := []struct {
+		priority Priority
+		levels   int
+		expected int
+	}{
+		{PriorityNone, 3, 0},
+		{PriorityLow, 3, 1},
+		{3, 3, 1},
+		{4, 3, 2},
+		{PriorityMedium, 3, 2},
+		{6, 3, 2},
+		{7, 3, 3},
+		{PriorityHigh, 3, 3},
+		{PriorityLow, 9, 1},
+		{6, 9, 6},
+		{PriorityHigh, 9, 9},
+	}
+	for _, tt := range tests {
+		if got := tt.priority.Rank(tt.levels); got != tt.expected {
+			t.Errorf("Test failed: priority %v ranks %d of %d, expected %d", tt.priority, got, tt.levels, tt.expected)
+		}
+	}
+}
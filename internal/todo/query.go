@@ -0,0 +1,459 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query is a parsed filter expression such as
+//
+//	done:false and (tag:work or priority>=2) and due<2026-11-01 and desc~"deploy"
+//
+// Terms are "<field><operator><value>" and are combined with and, or, not and
+// parentheses; adjacent terms without an operator are joined with and.
+type Query interface {
+	Match(task Task) bool
+}
+
+type QueryError struct {
+	Query   string
+	Pos     int
+	Token   string
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	location := "at the end of the query"
+	if e.Token != "" {
+		location = fmt.Sprintf("at %q (position %d)", e.Token, e.Pos+1)
+	}
+	return fmt.Sprintf("%s %s\n  %s\n  %s^", e.Message, location, e.Query, strings.Repeat(" ", e.Pos))
+}
+
+type andNode struct{ left, right Query }
+type orNode struct{ left, right Query }
+type notNode struct{ operand Query }
+type termNode struct{ match func(Task) bool }
+type matchAll struct{}
+
+func (n andNode) Match(task Task) bool  { return n.left.Match(task) && n.right.Match(task) }
+func (n orNode) Match(task Task) bool   { return n.left.Match(task) || n.right.Match(task) }
+func (n notNode) Match(task Task) bool  { return !n.operand.Match(task) }
+func (n termNode) Match(task Task) bool { return n.match(task) }
+func (matchAll) Match(Task) bool        { return true }
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenEnd
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value string // unquoted text of words and strings
+}
+
+var queryOperators = []string{"!=", "<=", ">=", ":", "=", "<", ">", "~"}
+
+const queryStopChars string = " \t\n():=!<>~\""
+
+func tokenize(input string) ([]token, error) {
+	tokens := []token{}
+	for pos := 0; pos < len(input); {
+		char := input[pos]
+		switch {
+		case strings.ContainsRune(" \t\n", rune(char)):
+			pos++
+		case char == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", pos: pos})
+			pos++
+		case char == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", pos: pos})
+			pos++
+		case char == '"':
+			var value strings.Builder
+			end := pos + 1
+			for ; end < len(input) && input[end] != '"'; end++ {
+				if input[end] == '\\' && end+1 < len(input) {
+					end++
+				}
+				value.WriteByte(input[end])
+			}
+			if end >= len(input) {
+				return nil, &QueryError{Query: input, Pos: pos, Token: input[pos:], Message: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: input[pos : end+1], pos: pos, value: value.String()})
+			pos = end + 1
+		default:
+			if operator := matchOperator(input[pos:]); operator != "" {
+				tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+				pos += len(operator)
+				continue
+			}
+			if char == '!' {
+				return nil, &QueryError{Query: input, Pos: pos, Token: "!", Message: "unexpected character"}
+			}
+			end := pos
+			for end < len(input) && !strings.ContainsRune(queryStopChars, rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[pos:end], pos: pos, value: input[pos:end]})
+			pos = end
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, pos: len(input)}), nil
+}
+
+func matchOperator(input string) string {
+	for _, operator := range queryOperators {
+		if strings.HasPrefix(input, operator) {
+			return operator
+		}
+	}
+	return ""
+}
+
+type queryParser struct {
+	input  string
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+// ParseQuery compiles a query; relative dates in it are resolved against Now.
+// An empty query matches every task.
+func ParseQuery(input string) (Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	parser := &queryParser{input: input, tokens: tokens, now: Now()}
+	if parser.peek().kind == tokenEnd {
+		return matchAll{}, nil
+	}
+	query, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := parser.peek(); next.kind != tokenEnd {
+		return nil, parser.errorAt(next, "unexpected token")
+	}
+	return query, nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	current := p.tokens[p.pos]
+	if current.kind != tokenEnd {
+		p.pos++
+	}
+	return current
+}
+
+func (p *queryParser) isKeyword(keyword string) bool {
+	next := p.peek()
+	return next.kind == tokenWord && strings.EqualFold(next.text, keyword)
+}
+
+func (p *queryParser) errorAt(at token, message string) error {
+	return &QueryError{Query: p.input, Pos: at.pos, Token: at.text, Message: message}
+}
+
+func (p *queryParser) parseOr() (Query, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (Query, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.isKeyword("and") {
+			p.next()
+		} else if next := p.peek(); next.kind == tokenEnd || next.kind == tokenClose || p.isKeyword("or") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (Query, error) {
+	if p.isKeyword("not") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	if p.peek().kind == tokenOpen {
+		open := p.next()
+		query, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			if p.peek().kind == tokenEnd {
+				return nil, p.errorAt(open, "unclosed parenthesis")
+			}
+			return nil, p.errorAt(p.peek(), "expected a closing parenthesis")
+		}
+		p.next()
+		return query, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *queryParser) parseTerm() (Query, error) {
+	fieldToken := p.next()
+	if fieldToken.kind != tokenWord {
+		return nil, p.errorAt(fieldToken, "expected a field name")
+	}
+	field, ok := queryFields[strings.ToLower(fieldToken.text)]
+	if !ok {
+		return nil, p.errorAt(fieldToken, fmt.Sprintf("unknown field, expected one of: %s", strings.Join(QueryFields(), ", ")))
+	}
+	operatorToken := p.next()
+	if operatorToken.kind != tokenOperator {
+		return nil, p.errorAt(operatorToken, fmt.Sprintf("expected an operator after %q", fieldToken.text))
+	}
+	if !slices.Contains(field.operators, operatorToken.text) {
+		return nil, p.errorAt(operatorToken, fmt.Sprintf("field %q supports only the operators %s", fieldToken.text, strings.Join(field.operators, " ")))
+	}
+	valueToken := p.next()
+	if valueToken.kind != tokenWord && valueToken.kind != tokenString {
+		return nil, p.errorAt(valueToken, fmt.Sprintf("expected a value after %q", operatorToken.text))
+	}
+	match, err := field.compile(operatorToken.text, valueToken.value, p.now)
+	if err != nil {
+		return nil, p.errorAt(valueToken, err.Error())
+	}
+	return termNode{match}, nil
+}
+
+type queryField struct {
+	operators []string
+	compile   func(operator string, value string, now time.Time) (func(Task) bool, error)
+}
+
+var (
+	equalityOperators   = []string{":", "=", "!="}
+	comparisonOperators = []string{":", "=", "!=", "<", "<=", ">", ">="}
+	textOperators       = []string{":", "=", "!=", "~"}
+)
+
+var queryFields = map[string]queryField{
+	"id": {comparisonOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id")
+		}
+		return func(t Task) bool { return compareWith(operator, t.ID-id) }, nil
+	}},
+	"parent": {comparisonOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		if strings.EqualFold(value, "none") {
+			if operator != ":" && operator != "=" && operator != "!=" {
+				return nil, fmt.Errorf("none can only be compared with : = !=")
+			}
+			return func(t Task) bool { return (t.Parent == nil) == (operator != "!=") }, nil
+		}
+		parent, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent id")
+		}
+		return func(t Task) bool { return t.Parent != nil && compareWith(operator, *t.Parent-parent) }, nil
+	}},
+	"notes": {textOperators, compileText(func(t Task) string { return t.Notes })},
+	"annotation": {textOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		return func(t Task) bool {
+			found := slices.ContainsFunc(t.Annotations, func(a Annotation) bool {
+				if operator == "~" {
+					return containsFold(a.Text, value)
+				}
+				return strings.EqualFold(a.Text, value)
+			})
+			return found == (operator != "!=")
+		}, nil
+	}},
+	"depends": {equalityOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		if strings.EqualFold(value, "none") {
+			return func(t Task) bool { return (len(t.DependsOn) == 0) == (operator != "!=") }, nil
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task id")
+		}
+		return func(t Task) bool { return slices.Contains(t.DependsOn, id) == (operator != "!=") }, nil
+	}},
+	"desc":        {textOperators, compileText(func(t Task) string { return t.Description })},
+	"description": {textOperators, compileText(func(t Task) string { return t.Description })},
+	"done": {equalityOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return func(t Task) bool { return (t.Status == StatusDone) == done == (operator != "!=") }, nil
+	}},
+	"status": {equalityOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		status, err := ParseStatus(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool { return (t.Status == status) == (operator != "!=") }, nil
+	}},
+	"priority": {comparisonOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		priority, err := ParsePriority(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool { return compareWith(operator, int(t.Priority-priority)) }, nil
+	}},
+	"estimate": {comparisonOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		if strings.EqualFold(value, "none") {
+			if operator != ":" && operator != "=" && operator != "!=" {
+				return nil, fmt.Errorf("none can only be compared with : = !=")
+			}
+			return func(t Task) bool { return (t.Estimate == nil) == (operator != "!=") }, nil
+		}
+		estimate, err := ParseEstimate(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool {
+			return t.Estimate != nil && t.Estimate.Unit == estimate.Unit && compareWith(operator, cmp.Compare(t.Estimate.Amount, estimate.Amount))
+		}, nil
+	}},
+	"project": {textOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		switch operator {
+		case ":":
+			return func(t Task) bool { return InProject(t, value) }, nil
+		case "~":
+			return func(t Task) bool { return containsFold(t.Project, value) }, nil
+		default:
+			return func(t Task) bool { return (t.Project == value) == (operator == "=") }, nil
+		}
+	}},
+	"tag": {textOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		value = strings.TrimPrefix(value, TagPrefix)
+		switch operator {
+		case "~":
+			return func(t Task) bool {
+				return slices.ContainsFunc(t.Tags, func(tag string) bool { return containsFold(tag, value) })
+			}, nil
+		default:
+			return func(t Task) bool { return HasTag(t, value) == (operator != "!=") }, nil
+		}
+	}},
+	"due":       {comparisonOperators, compileDate(func(t Task) *time.Time { return t.Due })},
+	"scheduled": {comparisonOperators, compileDate(func(t Task) *time.Time { return t.Scheduled })},
+	"created":   {comparisonOperators, compileDate(func(t Task) *time.Time { return t.CreatedAt })},
+	"updated":   {comparisonOperators, compileDate(func(t Task) *time.Time { return t.UpdatedAt })},
+	"completed": {comparisonOperators, compileDate(func(t Task) *time.Time { return t.CompletedAt })},
+	"archived":  {comparisonOperators, compileDate(func(t Task) *time.Time { return t.ArchivedAt })},
+	"is": {equalityOperators, func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		filter := TaskStateFilter(strings.ToLower(value))
+		if _, ok := DependencyFilterConditionsMap[filter]; ok {
+			return nil, fmt.Errorf("%s depends on other tasks, use the list -filter flag instead", filter)
+		}
+		condition, ok := FilterConditionsMap[filter]
+		if !ok {
+			return nil, fmt.Errorf("unknown state, expected one of the list filters")
+		}
+		return func(t Task) bool { return condition(t) == (operator != "!=") }, nil
+	}},
+}
+
+func QueryFields() []string {
+	fields := make([]string, 0, len(queryFields))
+	for field := range queryFields {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// compareWith turns the sign of a difference into the result of operator.
+func compareWith(operator string, difference int) bool {
+	switch operator {
+	case "<":
+		return difference < 0
+	case "<=":
+		return difference <= 0
+	case ">":
+		return difference > 0
+	case ">=":
+		return difference >= 0
+	case "!=":
+		return difference != 0
+	default:
+		return difference == 0
+	}
+}
+
+func containsFold(text string, part string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(part))
+}
+
+func compileText(get func(Task) string) func(string, string, time.Time) (func(Task) bool, error) {
+	return func(operator, value string, _ time.Time) (func(Task) bool, error) {
+		if operator == "~" {
+			return func(t Task) bool { return containsFold(get(t), value) }, nil
+		}
+		return func(t Task) bool { return strings.EqualFold(get(t), value) == (operator != "!=") }, nil
+	}
+}
+
+// compileDate compares by calendar day. The value "none" matches tasks without
+// the date; other comparisons never match them.
+func compileDate(get func(Task) *time.Time) func(string, string, time.Time) (func(Task) bool, error) {
+	return func(operator, value string, now time.Time) (func(Task) bool, error) {
+		if strings.EqualFold(value, "none") {
+			if operator != ":" && operator != "=" && operator != "!=" {
+				return nil, fmt.Errorf("none can only be compared with : = !=")
+			}
+			return func(t Task) bool { return (get(t) == nil) == (operator != "!=") }, nil
+		}
+		date, err := ParseDate(value, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(t Task) bool {
+			taskDate := get(t)
+			if taskDate == nil {
+				return false
+			}
+			return compareWith(operator, StartOfDay(taskDate.In(date.Location())).Compare(date))
+		}, nil
+	}
+}
@@ -0,0 +1,105 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	Now = func() time.Time { return time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC) }
+	defer func() { Now = time.Now }()
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	tasks := []Task{
+		{ID: 0, Description: "Deploy backend", Status: StatusTodo, Tags: []string{"work"}, Project: "work.backend", Due: day(20)},
+		{ID: 1, Description: "Buy milk", Status: StatusDone, Tags: []string{"home"}, Priority: PriorityLow},
+		{ID: 2, Description: "Review deploy script", Status: StatusReview, Priority: PriorityHigh, Estimate: &Estimate{4, Hours}, Due: day(30)},
+		{ID: 3, Description: "Call vendor", Status: StatusTodo, Priority: PriorityMedium, Project: "workshop", Due: day(17)},
+	}
+	tests := []struct {
+		query    string
+		expected []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{`done:false and (tag:work or priority>=2) and due<2026-11-01 and desc~"deploy"`, []int{0, 2}},
+		{"done:true", []int{1}},
+		{"not done:true", []int{0, 2, 3}},
+		{"tag:work or tag:+home", []int{0, 1}},
+		{"tag!=work", []int{1, 2, 3}},
+		{"priority>=M", []int{2, 3}},
+		{"priority=0", []int{0}},
+		{"id>1 id<=3", []int{2, 3}},
+		{"project:work", []int{0}},
+		{"project=work", []int{}},
+		{"project~work", []int{0, 3}},
+		{"due:none", []int{1}},
+		{"due!=none", []int{0, 2, 3}},
+		{"due<=tomorrow", []int{3}},
+		{"due:+2d", []int{0}},
+		{"is:overdue", []int{3}},
+		{"status:review", []int{2}},
+		{"estimate>=3h", []int{2}},
+		{"estimate<3pt", []int{}},
+		{"estimate:none", []int{0, 1, 3}},
+		{"status!=todo", []int{1, 2}},
+		{`desc="buy milk"`, []int{1}},
+		{"DONE:false AND NOT (priority>5 OR project:work)", []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			got := Filter(tasks, query.Match)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Test failed: got %v, expected ids %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i].ID != tt.expected[i] {
+					t.Errorf("Test failed: got %v, expected ids %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query       string
+		expectedPos int
+		message     string
+	}{
+		{"colour:red", 0, "unknown field"},
+		{"done", 4, "expected an operator"},
+		{"done:", 5, "expected a value"},
+		{"done:maybe", 5, "expected true or false"},
+		{"status:blocked", 7, "unknown status"},
+		{"tag<work", 3, "supports only the operators"},
+		{"(done:true", 0, "unclosed parenthesis"},
+		{"done:true)", 9, "unexpected token"},
+		{`desc~"deploy`, 5, "unterminated string"},
+		{"due<someday", 4, "unrecognised date"},
+		{"done:true or", 12, "expected a field name"},
+		{"done ! true", 5, "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Test failed: expected a QueryError, got %v", err)
+			}
+			if queryErr.Pos != tt.expectedPos {
+				t.Errorf("Test failed: error at position %d, expected %d: %v", queryErr.Pos, tt.expectedPos, err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Test failed: error %q does not mention %q", err, tt.message)
+			}
+		})
+	}
+}
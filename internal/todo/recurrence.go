@@ -0,0 +1,261 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var frequencyUnits = map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Recurrence is a repeat rule written in a subset of iCalendar RRULE syntax:
+// FREQ, INTERVAL and BYDAY (weekly rules only), plus FROM=COMPLETION for rules
+// counting from the day the task was completed instead of its due date, e.g.
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH" or "FREQ=DAILY;INTERVAL=3;FROM=COMPLETION".
+type Recurrence struct {
+	Frequency      Frequency
+	Interval       int
+	Weekdays       []time.Weekday
+	FromCompletion bool
+}
+
+// ParseRecurrence accepts the RRULE form as well as shorthands: daily,
+// weekly, monthly, yearly, "every 2 weeks", weekday lists such as
+// "mon,wed,fri", each optionally followed by "after completion".
+func ParseRecurrence(input string) (Recurrence, error) {
+	input = strings.TrimSpace(input)
+	if strings.Contains(strings.ToUpper(input), "FREQ=") {
+		return parseRRule(input)
+	}
+	text := strings.ToLower(input)
+	rule := Recurrence{Interval: 1}
+	if before, ok := strings.CutSuffix(text, " after completion"); ok {
+		text = strings.TrimSpace(before)
+		rule.FromCompletion = true
+	}
+	for frequency, unit := range frequencyUnits {
+		if text == strings.ToLower(string(frequency)) || text == "every "+unit {
+			rule.Frequency = frequency
+		}
+	}
+	if fields := strings.Fields(text); rule.Frequency == "" && len(fields) == 3 && fields[0] == "every" {
+		interval, err := strconv.Atoi(fields[1])
+		if err != nil || interval < 1 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence interval %q", fields[1])
+		}
+		for frequency, unit := range frequencyUnits {
+			if fields[2] == unit+"s" {
+				rule.Frequency, rule.Interval = frequency, interval
+			}
+		}
+	}
+	if rule.Frequency == "" {
+		for _, name := range strings.Split(text, ",") {
+			weekday, ok := weekdays[strings.TrimSpace(name)]
+			if !ok {
+				return Recurrence{}, fmt.Errorf("unknown recurrence %q", input)
+			}
+			rule.Weekdays = append(rule.Weekdays, weekday)
+		}
+		rule.Frequency = Weekly
+	}
+	return rule, rule.validate()
+}
+
+func parseRRule(input string) (Recurrence, error) {
+	rule := Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.ToUpper(input), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("invalid recurrence part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			rule.Frequency = Frequency(value)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil {
+				return Recurrence{}, fmt.Errorf("invalid recurrence interval %q", value)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, name := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[name]
+				if !ok {
+					return Recurrence{}, fmt.Errorf("invalid recurrence weekday %q", name)
+				}
+				rule.Weekdays = append(rule.Weekdays, weekday)
+			}
+		case "FROM":
+			if value != "COMPLETION" && value != "DUE" {
+				return Recurrence{}, fmt.Errorf("invalid recurrence base %q, expected COMPLETION or DUE", value)
+			}
+			rule.FromCompletion = value == "COMPLETION"
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported recurrence part %q", key)
+		}
+	}
+	return rule, rule.validate()
+}
+
+func (r Recurrence) validate() error {
+	if _, ok := frequencyUnits[r.Frequency]; !ok {
+		return fmt.Errorf("unsupported recurrence frequency %q", r.Frequency)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("recurrence interval must be positive, got %d", r.Interval)
+	}
+	if len(r.Weekdays) > 0 && r.Frequency != Weekly {
+		return fmt.Errorf("weekdays can only be used with weekly recurrence")
+	}
+	if len(r.Weekdays) > 0 && r.FromCompletion {
+		return fmt.Errorf("weekdays cannot be combined with recurrence after completion")
+	}
+	return nil
+}
+
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.Weekdays) > 0 {
+		names := []string{}
+		for _, weekday := range r.sortedWeekdays() {
+			for name, day := range rruleWeekdays {
+				if day == weekday {
+					names = append(names, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if r.FromCompletion {
+		parts = append(parts, "FROM=COMPLETION")
+	}
+	return strings.Join(parts, ";")
+}
+
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Recurrence) UnmarshalText(text []byte) error {
+	rule, err := parseRRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// Next returns the first occurrence after from.
+func (r Recurrence) Next(from time.Time) time.Time {
+	switch r.Frequency {
+	case Weekly:
+		if len(r.Weekdays) == 0 {
+			return from.AddDate(0, 0, 7*r.Interval)
+		}
+		for days := 1; days <= 7; days++ {
+			next := from.AddDate(0, 0, days)
+			if !slices.Contains(r.Weekdays, next.Weekday()) {
+				continue
+			}
+			if weekIndex(next.Weekday()) <= weekIndex(from.Weekday()) {
+				// the week is over, skip the weeks the interval leaves out
+				next = next.AddDate(0, 0, 7*(r.Interval-1))
+			}
+			return next
+		}
+		return from.AddDate(0, 0, 7*r.Interval)
+	case Monthly:
+		return addMonths(from, r.Interval)
+	case Yearly:
+		return addMonths(from, 12*r.Interval)
+	default:
+		return from.AddDate(0, 0, r.Interval)
+	}
+}
+
+func (r Recurrence) sortedWeekdays() []time.Weekday {
+	days := slices.Clone(r.Weekdays)
+	slices.SortFunc(days, func(a, b time.Weekday) int { return weekIndex(a) - weekIndex(b) })
+	return slices.Compact(days)
+}
+
+// weekIndex numbers weekdays from Monday, the first day of an RRULE week.
+func weekIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// addMonths keeps the day of month, moving to the last day of shorter months
+// instead of overflowing into the next one.
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
+
+// nextOccurrence builds the pending copy of a recurring task completed at
+// now. The due date moves by the rule, skipping occurrences that are already
+// in the past, and the scheduled date keeps its distance to the due date.
+func nextOccurrence(task Task, now time.Time) Task {
+	rule := *task.Recurrence
+	next := task
+	next.Status = ActiveWorkflow.Initial()
+	next.CompletedAt = nil
+	next.Intervals = nil
+	// the copy is a new task to the application the task came from
+	next.UUID = ""
+	next.Tags = slices.Clone(task.Tags)
+	next.DependsOn = slices.Clone(task.DependsOn)
+
+	anchor := task.Due
+	if anchor == nil {
+		anchor = task.Scheduled
+	}
+	base := StartOfDay(now)
+	if anchor != nil && !rule.FromCompletion {
+		base = *anchor
+	}
+	date := rule.Next(base)
+	for !rule.FromCompletion && !date.After(StartOfDay(now)) {
+		date = rule.Next(date)
+	}
+	if anchor != nil {
+		shift := date.Sub(*anchor)
+		if task.Due != nil {
+			due := shiftDate(*task.Due, shift)
+			next.Due = &due
+		}
+		if task.Scheduled != nil {
+			scheduled := shiftDate(*task.Scheduled, shift)
+			next.Scheduled = &scheduled
+		}
+	} else {
+		next.Due = &date
+	}
+	return next
+}
+
+// shiftDate moves a date by whole days so that daylight saving changes do not
+// move it off midnight.
+func shiftDate(date time.Time, shift time.Duration) time.Time {
+	return date.AddDate(0, 0, int((shift+12*time.Hour).Hours()/24))
+}
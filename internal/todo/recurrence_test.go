@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      string
+		errorExpected bool
+	}{
+		{"daily", "FREQ=DAILY", false},
+		{"Weekly", "FREQ=WEEKLY", false},
+		{"every month", "FREQ=MONTHLY", false},
+		{"every 2 weeks", "FREQ=WEEKLY;INTERVAL=2", false},
+		{"every 3 days after completion", "FREQ=DAILY;INTERVAL=3;FROM=COMPLETION", false},
+		{"fri, mon,wed", "FREQ=WEEKLY;BYDAY=MO,WE,FR", false},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", false},
+		{"RRULE:FREQ=YEARLY", "FREQ=YEARLY", false},
+		{"freq=monthly;from=completion", "FREQ=MONTHLY;FROM=COMPLETION", false},
+		{"every 0 days", "", true},
+		{"every 2 fortnights", "", true},
+		{"sometimes", "", true},
+		{"FREQ=HOURLY", "", true},
+		{"FREQ=DAILY;BYDAY=MO", "", true},
+		{"FREQ=WEEKLY;COUNT=3", "", true},
+		{"mon after completion", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.input)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && got.String() != tt.expected {
+				t.Errorf("Test failed: got %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	tests := []struct {
+		rule     string
+		from     string
+		expected string
+	}{
+		{"every 3 days", "2026-10-18", "2026-10-21"},
+		{"weekly", "2026-10-18", "2026-10-25"},
+		{"mon,thu", "2026-10-19", "2026-10-22"}, // Monday -> Thursday
+		{"mon,thu", "2026-10-22", "2026-10-26"}, // Thursday -> next Monday
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2026-10-22", "2026-11-02"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2026-10-19", "2026-10-22"},
+		{"monthly", "2026-01-31", "2026-02-28"},
+		{"every 2 months", "2026-10-18", "2026-12-18"},
+		{"yearly", "2028-02-29", "2029-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule+" from "+tt.from, func(t *testing.T) {
+			rule, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			from, _ := time.Parse(DateLayout, tt.from)
+			if got := rule.Next(from).Format(DateLayout); got != tt.expected {
+				t.Errorf("Test failed: got %s, expected %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompleteRecurring(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return now }
+	defer func() { Now = time.Now }()
+	date := func(value string) *time.Time {
+		parsed, _ := time.Parse(DateLayout, value)
+		return &parsed
+	}
+	rule := func(value string) *Recurrence {
+		parsed, _ := ParseRecurrence(value)
+		return &parsed
+	}
+
+	tests := []struct {
+		name              string
+		task              Task
+		expectedDue       string
+		expectedScheduled string
+	}{
+		{"from the due date", Task{Due: date("2026-10-20"), Recurrence: rule("weekly")}, "2026-10-27", ""},
+		{"skips missed occurrences", Task{Due: date("2026-10-10"), Recurrence: rule("every 3 days")}, "2026-10-19", ""},
+		{"after completion", Task{Due: date("2026-10-10"), Recurrence: rule("every 3 days after completion")}, "2026-10-21", ""},
+		{"without dates", Task{Recurrence: rule("daily")}, "2026-10-19", ""},
+		{"keeps the scheduled offset", Task{Due: date("2026-10-20"), Scheduled: date("2026-10-18"), Recurrence: rule("monthly")}, "2026-11-20", "2026-11-18"},
+		{"scheduled only", Task{Scheduled: date("2026-10-18"), Recurrence: rule("tue")}, "", "2026-10-20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.Description, tt.task.UUID = "Recurring", "1f2d3c4b-0000-4000-8000-000000000001"
+			list := Add(TaskList{NextID: 5}, tt.task)
+			list, err := Complete(list, 5)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			if len(list.Tasks) != 2 || list.NextID != 7 || list.Tasks[0].Status != StatusDone {
+				t.Fatalf("Test failed: unexpected list %+v", list)
+			}
+			next := list.Tasks[1]
+			if next.ID != 6 || next.Status == StatusDone || next.CompletedAt != nil || next.Recurrence == nil || next.UUID != "" {
+				t.Errorf("Test failed: unexpected next occurrence %+v", next)
+			}
+			if got := formatOptionalDate(next.Due); got != tt.expectedDue {
+				t.Errorf("Test failed: next due %s, expected %s", got, tt.expectedDue)
+			}
+			if got := formatOptionalDate(next.Scheduled); got != tt.expectedScheduled {
+				t.Errorf("Test failed: next scheduled %s, expected %s", got, tt.expectedScheduled)
+			}
+
+			list, _ = Complete(list, 5)
+			if len(list.Tasks) != 2 {
+				t.Error("Test failed: completing a done task spawned another occurrence")
+			}
+		})
+	}
+}
+
+func formatOptionalDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(DateLayout)
+}
@@ -0,0 +1,116 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortTopo is the sort key placing every task after the tasks it depends on.
+const SortTopo string = "topo"
+
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+var SortFieldsMap = map[string]func(a, b Task) int{
+	"id": func(a, b Task) int { return cmp.Compare(a.ID, b.ID) },
+	"description": func(a, b Task) int {
+		return cmp.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+	},
+	"done":      func(a, b Task) int { return compareBools(a.Status == StatusDone, b.Status == StatusDone) },
+	"status":    func(a, b Task) int { return cmp.Compare(statusIndex(a.Status), statusIndex(b.Status)) },
+	"priority":  func(a, b Task) int { return cmp.Compare(a.Priority, b.Priority) },
+	"project":   func(a, b Task) int { return cmp.Compare(a.Project, b.Project) },
+	"due":       func(a, b Task) int { return compareTimes(a.Due, b.Due) },
+	"scheduled": func(a, b Task) int { return compareTimes(a.Scheduled, b.Scheduled) },
+	"created":   func(a, b Task) int { return compareTimes(a.CreatedAt, b.CreatedAt) },
+	"updated":   func(a, b Task) int { return compareTimes(a.UpdatedAt, b.UpdatedAt) },
+	"completed": func(a, b Task) int { return compareTimes(a.CompletedAt, b.CompletedAt) },
+	"archived":  func(a, b Task) int { return compareTimes(a.ArchivedAt, b.ArchivedAt) },
+}
+
+// ParseSort reads comma-separated field names, each optionally prefixed with
+// "-" for descending order, e.g. "priority,-due,id".
+func ParseSort(spec string) ([]SortKey, error) {
+	keys := []SortKey{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Descending: strings.HasPrefix(part, "-")}
+		if key.Field == SortTopo && key.Descending {
+			return []SortKey{}, fmt.Errorf("the %s sort key cannot be reversed", SortTopo)
+		}
+		if _, ok := SortFieldsMap[key.Field]; !ok && key.Field != SortTopo {
+			return []SortKey{}, fmt.Errorf("unknown sort key %q, expected one of: %s", key.Field, strings.Join(SortFields(), ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func SortFields() []string {
+	fields := make([]string, 0, len(SortFieldsMap)+1)
+	for field := range SortFieldsMap {
+		fields = append(fields, field)
+	}
+	fields = append(fields, SortTopo)
+	slices.Sort(fields)
+	return fields
+}
+
+// Sort orders tasks in place by the keys in turn; ties keep their original
+// order. With SortTopo, wherever it is given, dependencies come first and the
+// other keys order the tasks that are free to go in either order.
+func Sort(tasks []Task, keys ...SortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	topo := slices.ContainsFunc(keys, func(key SortKey) bool { return key.Field == SortTopo })
+	keys = slices.DeleteFunc(slices.Clone(keys), func(key SortKey) bool { return key.Field == SortTopo })
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		for _, key := range keys {
+			result := SortFieldsMap[key.Field](a, b)
+			if key.Descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+	if topo {
+		TopoSort(tasks)
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compareTimes places unset times after set ones.
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return a.Compare(*b)
+	}
+}
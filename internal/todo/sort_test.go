@@ -0,0 +1,73 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec          string
+		expected      []SortKey
+		errorExpected bool
+	}{
+		{"", []SortKey{}, false},
+		{"priority,-due,id", []SortKey{{"priority", false}, {"due", true}, {"id", false}}, false},
+		{" -Priority , id ", []SortKey{{"priority", true}, {"id", false}}, false},
+		{"priority,color", []SortKey{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSort(tt.spec)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	tasks := []Task{
+		{ID: 0, Priority: PriorityLow, Due: day(20)},
+		{ID: 1, Priority: PriorityHigh},
+		{ID: 2, Priority: PriorityHigh, Due: day(25)},
+		{ID: 3, Priority: PriorityLow, Due: day(19)},
+		{ID: 4, Priority: PriorityHigh, Due: day(21)},
+	}
+	tests := []struct {
+		name     string
+		spec     string
+		expected []int
+	}{
+		{"no keys keeps order", "", []int{0, 1, 2, 3, 4}},
+		{"descending priority then due", "-priority,due", []int{4, 2, 1, 3, 0}},
+		{"due puts unset dates last", "due", []int{3, 0, 4, 2, 1}},
+		{"descending id", "-id", []int{4, 3, 2, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseSort(tt.spec)
+			if err != nil {
+				t.Fatalf("Test failed: Unexpected error: %v", err)
+			}
+			sorted := List(append([]Task{}, tasks...), string(FilterAll), keys...)
+			for i := range sorted {
+				if sorted[i].ID != tt.expected[i] {
+					t.Fatalf("Test failed: got order %v, expected ids %v", sorted, tt.expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in-progress"
+	StatusReview     Status = "review"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Closed reports whether no more work is expected on a task in this status.
+func (s Status) Closed() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+var (
+	ErrTransition   = errors.New("the workflow does not allow this status change")
+	ErrOpenSubtasks = errors.New("task has open subtasks")
+)
+
+// Workflow lists the statuses a task can have, in display order, and the
+// status changes allowed between them. The first status is given to new and
+// reopened tasks. Done and cancelled are part of every workflow and are its
+// only closed statuses.
+type Workflow struct {
+	Statuses    []Status
+	Transitions map[Status][]Status
+}
+
+var DefaultWorkflow = Workflow{
+	Statuses: []Status{StatusTodo, StatusInProgress, StatusReview, StatusDone, StatusCancelled},
+	Transitions: map[Status][]Status{
+		StatusTodo:       {StatusInProgress, StatusDone, StatusCancelled},
+		StatusInProgress: {StatusTodo, StatusReview, StatusDone, StatusCancelled},
+		StatusReview:     {StatusInProgress, StatusDone, StatusCancelled},
+		StatusDone:       {StatusTodo},
+		StatusCancelled:  {StatusTodo},
+	},
+}
+
+// ActiveWorkflow is the workflow status changes and loaded tasks are checked
+// against. The command line replaces it when a custom workflow is configured.
+var ActiveWorkflow = DefaultWorkflow
+
+var statusPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// ParseWorkflow reads transitions written as "from > to, to; from > to", e.g.
+// "todo > doing, done, cancelled; doing > done; done > todo; cancelled > todo".
+// Statuses are ordered by their first appearance; the first one is initial.
+func ParseWorkflow(spec string) (Workflow, error) {
+	workflow := Workflow{Transitions: map[Status][]Status{}}
+	add := func(name string) (Status, error) {
+		status := Status(strings.ToLower(strings.TrimSpace(name)))
+		if !statusPattern.MatchString(string(status)) {
+			return "", fmt.Errorf("invalid status name %q", name)
+		}
+		if !slices.Contains(workflow.Statuses, status) {
+			workflow.Statuses = append(workflow.Statuses, status)
+		}
+		return status, nil
+	}
+	for _, rule := range strings.Split(spec, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		fromName, targets, ok := strings.Cut(rule, ">")
+		if !ok {
+			return Workflow{}, fmt.Errorf("invalid workflow rule %q, expected from > to, to", strings.TrimSpace(rule))
+		}
+		from, err := add(fromName)
+		if err != nil {
+			return Workflow{}, err
+		}
+		for _, name := range strings.Split(targets, ",") {
+			to, err := add(name)
+			if err != nil {
+				return Workflow{}, err
+			}
+			if to != from && !slices.Contains(workflow.Transitions[from], to) {
+				workflow.Transitions[from] = append(workflow.Transitions[from], to)
+			}
+		}
+	}
+	return workflow, workflow.validate()
+}
+
+func (w Workflow) validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("the workflow has no statuses")
+	}
+	for _, required := range []Status{StatusDone, StatusCancelled} {
+		if !slices.Contains(w.Statuses, required) {
+			return fmt.Errorf("the workflow has no %s status", required)
+		}
+	}
+	if w.Initial().Closed() {
+		return fmt.Errorf("the initial status %s cannot be a closed one", w.Initial())
+	}
+	return nil
+}
+
+func (w Workflow) Initial() Status {
+	return w.Statuses[0]
+}
+
+// Allows reports whether a task may move from one status to another; staying
+// in the same status is always allowed.
+func (w Workflow) Allows(from Status, to Status) bool {
+	return from == to || slices.Contains(w.Transitions[from], to)
+}
+
+func (w Workflow) String() string {
+	rules := []string{}
+	for _, status := range w.Statuses {
+		if targets := w.Transitions[status]; len(targets) > 0 {
+			names := make([]string, len(targets))
+			for i, target := range targets {
+				names[i] = string(target)
+			}
+			rules = append(rules, fmt.Sprintf("%s > %s", status, strings.Join(names, ", ")))
+		}
+	}
+	return strings.Join(rules, "; ")
+}
+
+// ParseStatus checks value against the active workflow.
+func ParseStatus(value string) (Status, error) {
+	status := Status(strings.ToLower(strings.TrimSpace(value)))
+	if !slices.Contains(ActiveWorkflow.Statuses, status) {
+		return "", fmt.Errorf("unknown status %q, expected one of: %s", value, strings.Join(StatusNames(), ", "))
+	}
+	return status, nil
+}
+
+func StatusNames() []string {
+	names := make([]string, len(ActiveWorkflow.Statuses))
+	for i, status := range ActiveWorkflow.Statuses {
+		names[i] = string(status)
+	}
+	return names
+}
+
+// statusIndex orders statuses as the active workflow lists them, unknown
+// ones last.
+func statusIndex(status Status) int {
+	if i := slices.Index(ActiveWorkflow.Statuses, status); i >= 0 {
+		return i
+	}
+	return len(ActiveWorkflow.Statuses)
+}
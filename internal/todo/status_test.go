@@ -0,0 +1,133 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	tests := []struct {
+		spec          string
+		statuses      []Status
+		errorExpected bool
+	}{
+		{"todo > doing, done, cancelled; doing > done; done > todo; cancelled > todo", []Status{"todo", "doing", "done", "cancelled"}, false},
+		{" Backlog > Done ; backlog > cancelled ;", []Status{"backlog", "done", "cancelled"}, false},
+		{"", nil, true},
+		{"todo > done", nil, true},
+		{"done > todo, cancelled", nil, true},
+		{"todo, done, cancelled", nil, true},
+		{"todo > done, cancelled, in progress", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			workflow, err := ParseWorkflow(tt.spec)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && !slices.Equal(workflow.Statuses, tt.statuses) {
+				t.Errorf("Test failed: statuses %v, expected %v", workflow.Statuses, tt.statuses)
+			}
+		})
+	}
+
+	workflow, _ := ParseWorkflow("todo > doing, done, cancelled; doing > done")
+	if !workflow.Allows("todo", "doing") || workflow.Allows("doing", "todo") || !workflow.Allows("done", "done") {
+		t.Errorf("Test failed: unexpected transitions %s", workflow)
+	}
+	if workflow.String() != "todo > doing, done, cancelled; doing > done" {
+		t.Errorf("Test failed: unexpected string %q", workflow.String())
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	tasks := func() TaskList {
+		return NewTaskList([]Task{
+			{ID: 0, Description: "Design", Status: StatusTodo},
+			{ID: 1, Description: "Schema", Status: StatusDone},
+			{ID: 2, Description: "API", Status: StatusReview},
+		}, 0)
+	}
+	tests := []struct {
+		name          string
+		id            int
+		status        Status
+		errorExpected bool
+	}{
+		{"start", 0, StatusInProgress, false},
+		{"cancel", 0, StatusCancelled, false},
+		{"reopen", 1, StatusTodo, false},
+		{"same status", 1, StatusDone, false},
+		{"review to todo", 2, StatusTodo, true},
+		{"start done task", 1, StatusInProgress, true},
+		{"missing", 9, StatusDone, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := SetStatus(tasks(), tt.id, tt.status)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err == nil && list.Tasks[tt.id].Status != tt.status {
+				t.Errorf("Test failed: status %s, expected %s", list.Tasks[tt.id].Status, tt.status)
+			}
+			if tt.name == "review to todo" && !errors.Is(err, ErrTransition) {
+				t.Errorf("Test failed: unexpected error %v", err)
+			}
+		})
+	}
+
+	_, result := SetStatusMany(tasks(), []int{0, 1, 2, 9}, StatusInProgress)
+	if !slices.Equal(result.Affected, []int{0, 2}) || !slices.Equal(result.Rejected, []int{1}) || !slices.Equal(result.Missing, []int{9}) {
+		t.Errorf("Test failed: unexpected result %+v", result)
+	}
+}
+
+func TestCancelWithSubtasks(t *testing.T) {
+	list := NewTaskList(hierarchyTasks(), 0)
+	if _, err := SetStatus(list, 1, StatusCancelled); !errors.Is(err, ErrOpenSubtasks) {
+		t.Errorf("Test failed: cancelled a task with open subtasks: %v", err)
+	}
+	list, _ = SetStatus(list, 3, StatusCancelled)
+	list, err := Complete(list, 1)
+	if err != nil {
+		t.Fatalf("Test failed: a cancelled subtask blocked completion: %v", err)
+	}
+	if nodes := Tree(list.Tasks, list.Tasks); nodes[0].Done != 2 || nodes[0].Total != 2 {
+		t.Errorf("Test failed: cancelled subtask counted in progress %d/%d", nodes[0].Done, nodes[0].Total)
+	}
+}
+
+func TestUnmarshalLegacyDone(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected Status
+	}{
+		{`{"ID": 0, "Description": "Task", "Done": true}`, StatusDone},
+		{`{"ID": 0, "Description": "Task", "Done": false}`, StatusTodo},
+		{`{"ID": 0, "Description": "Task"}`, StatusTodo},
+		{`{"ID": 0, "Description": "Task", "Status": "review"}`, StatusReview},
+	}
+	for _, tt := range tests {
+		var task Task
+		if err := json.Unmarshal([]byte(tt.data), &task); err != nil {
+			t.Fatalf("Test failed: Unexpected error: %v", err)
+		}
+		if task.Status != tt.expected {
+			t.Errorf("Test failed: %s decoded to status %s, expected %s", tt.data, task.Status, tt.expected)
+		}
+	}
+	var task Task
+	if err := json.Unmarshal([]byte(`{"ID": 0, "Description": "Task", "Finished": true}`), &task); err == nil {
+		t.Error("Test failed: accepted an unknown field")
+	}
+}
+
+func TestValidateStatus(t *testing.T) {
+	tasks := []Task{{ID: 0, Description: "Task", Status: "blocked"}}
+	if err := Validate(NewTaskList(tasks, 1)); err == nil {
+		t.Error("Test failed: accepted a status unknown to the workflow")
+	}
+}
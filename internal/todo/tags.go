@@ -0,0 +1,113 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	TagPrefix     string = "+"
+	ProjectPrefix string = "project:"
+)
+
+// ParseDescription pulls "+tag" and "project:name" words out of a description
+// typed on the command line, returning the remaining text separately.
+func ParseDescription(input string) (desc string, tags []string, project string, err error) {
+	words := []string{}
+	tags = []string{}
+	for _, word := range strings.Fields(input) {
+		switch {
+		case strings.HasPrefix(word, TagPrefix) && len(word) > len(TagPrefix):
+			tags = append(tags, strings.TrimPrefix(word, TagPrefix))
+		case strings.HasPrefix(word, ProjectPrefix):
+			project = strings.TrimPrefix(word, ProjectPrefix)
+			if err := ValidateProject(project); err != nil {
+				return "", nil, "", err
+			}
+		default:
+			words = append(words, word)
+		}
+	}
+	tags, err = NormalizeTags(tags)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return strings.Join(words, " "), tags, project, nil
+}
+
+// NormalizeTags validates tags and drops duplicates, keeping the first
+// occurrence order. A leading "+" is accepted and stripped.
+func NormalizeTags(tags []string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), TagPrefix)
+		if err := ValidateTag(tag); err != nil {
+			return []string{}, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("empty tag")
+	}
+	if strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) {
+		return fmt.Errorf("invalid tag %q, tags cannot contain spaces or commas", tag)
+	}
+	return nil
+}
+
+// Projects are dot-separated paths such as "work.backend"; an empty project is allowed.
+func ValidateProject(project string) error {
+	if strings.ContainsFunc(project, unicode.IsSpace) {
+		return fmt.Errorf("invalid project %q, projects cannot contain spaces", project)
+	}
+	for _, part := range strings.Split(project, ".") {
+		if part == "" && project != "" {
+			return fmt.Errorf("invalid project %q, empty path segment", project)
+		}
+	}
+	return nil
+}
+
+func HasTag(task Task, tag string) bool {
+	for _, taskTag := range task.Tags {
+		if taskTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// InProject reports whether the task belongs to project or any of its
+// sub-projects, so "work" matches "work.backend" but not "workshop".
+func InProject(task Task, project string) bool {
+	return task.Project == project || strings.HasPrefix(task.Project, project+".")
+}
+
+func TagCounts(tasks []Task) map[string]int {
+	counts := map[string]int{}
+	for _, task := range tasks {
+		for _, tag := range task.Tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+func Filter(tasks []Task, condition func(Task) bool) []Task {
+	result := []Task{}
+	for _, task := range tasks {
+		if condition(task) {
+			result = append(result, task)
+		}
+	}
+	return result
+}
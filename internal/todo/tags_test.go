@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expectedDesc    string
+		expectedTags    []string
+		expectedProject string
+		errorExpected   bool
+	}{
+		{"plain text", "Buy milk", "Buy milk", []string{}, "", false},
+		{"tags and project", "Fix +bug login project:work.backend +urgent", "Fix login", []string{"bug", "urgent"}, "work.backend", false},
+		{"duplicate tags", "Call +phone +phone", "Call", []string{"phone"}, "", false},
+		{"lone plus is text", "1 + 1", "1 + 1", []string{}, "", false},
+		{"invalid project", "Task project:work..backend", "", nil, "", true},
+		{"tag with a comma", "Task +a,b", "", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, tags, project, err := ParseDescription(tt.input)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if err != nil {
+				return
+			}
+			if desc != tt.expectedDesc || !slices.Equal(tags, tt.expectedTags) || project != tt.expectedProject {
+				t.Errorf("Test failed: got (%q, %v, %q), expected (%q, %v, %q)",
+					desc, tags, project, tt.expectedDesc, tt.expectedTags, tt.expectedProject)
+			}
+		})
+	}
+}
+
+func TestInProject(t *testing.T) {
+	tests := []struct {
+		taskProject string
+		project     string
+		expected    bool
+	}{
+		{"work", "work", true},
+		{"work.backend", "work", true},
+		{"work.backend.db", "work.backend", true},
+		{"workshop", "work", false},
+		{"work", "work.backend", false},
+		{"", "work", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.taskProject+" in "+tt.project, func(t *testing.T) {
+			if got := InProject(Task{Project: tt.taskProject}, tt.project); got != tt.expected {
+				t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTagCounts(t *testing.T) {
+	tasks := []Task{
+		{ID: 0, Tags: []string{"work", "urgent"}},
+		{ID: 1, Tags: []string{"work"}},
+		{ID: 2},
+	}
+	counts := TagCounts(tasks)
+	if len(counts) != 2 || counts["work"] != 2 || counts["urgent"] != 1 {
+		t.Errorf("Test failed: unexpected counts %v", counts)
+	}
+	tagged := Filter(tasks, func(task Task) bool { return HasTag(task, "work") })
+	if len(tagged) != 2 {
+		t.Errorf("Test failed: expected 2 tasks tagged work, got %v", tagged)
+	}
+}
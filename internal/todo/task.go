@@ -1,13 +1,176 @@
 package todo
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 type Task struct {
-	ID          int
-	Description string
-	Done        bool
+	ID          int          `yaml:"ID"`
+	Description string       `yaml:"Description"`
+	Status      Status       `yaml:"Status"`
+	Due         *time.Time   `json:",omitempty" yaml:"Due,omitempty" toml:",omitempty"`
+	Scheduled   *time.Time   `json:",omitempty" yaml:"Scheduled,omitempty" toml:",omitempty"`
+	Priority    Priority     `json:",omitempty" yaml:"Priority,omitempty" toml:",omitzero"`
+	Estimate    *Estimate    `json:",omitempty" yaml:"Estimate,omitempty" toml:",omitempty"`
+	Project     string       `json:",omitempty" yaml:"Project,omitempty" toml:",omitempty"`
+	Tags        []string     `json:",omitempty" yaml:"Tags,omitempty" toml:",omitempty"`
+	Parent      *int         `json:",omitempty" yaml:"Parent,omitempty" toml:",omitempty"`
+	DependsOn   []int        `json:",omitempty" yaml:"DependsOn,omitempty" toml:",omitempty"`
+	Recurrence  *Recurrence  `json:",omitempty" yaml:"Recurrence,omitempty" toml:",omitempty"`
+	Annotations []Annotation `json:",omitempty" yaml:"Annotations,omitempty" toml:",omitempty"`
+	Notes       string       `json:",omitempty" yaml:"Notes,omitempty" toml:",omitempty"`
+	Intervals   []Interval   `json:",omitempty" yaml:"Intervals,omitempty" toml:",omitempty"`
+	CreatedAt   *time.Time   `json:",omitempty" yaml:"CreatedAt,omitempty" toml:",omitempty"`
+	UpdatedAt   *time.Time   `json:",omitempty" yaml:"UpdatedAt,omitempty" toml:",omitempty"`
+	CompletedAt *time.Time   `json:",omitempty" yaml:"CompletedAt,omitempty" toml:",omitempty"`
+	ArchivedAt  *time.Time   `json:",omitempty" yaml:"ArchivedAt,omitempty" toml:",omitempty"`
+	// UUID is the id the task had in the application it was imported from,
+	// written back when exporting to it
+	UUID string `json:",omitempty" yaml:"UUID,omitempty" toml:",omitempty"`
 }
 
 func (t Task) String() string {
-	return fmt.Sprintf("%d. %s: %t", t.ID, t.Description, t.Done)
+	result := fmt.Sprintf("%d. %s: %s", t.ID, t.Description, t.Status)
+	if details := t.details(); len(details) > 0 {
+		result += " (" + strings.Join(details, ", ") + ")"
+	}
+	return result
+}
+
+// UnmarshalJSON reads files written before statuses replaced the Done flag:
+// Done true becomes done, false or a missing status the initial one.
+// Unknown fields are rejected.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plainTask Task
+	var task struct {
+		plainTask
+		Done *bool
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&task); err != nil {
+		return err
+	}
+	*t = Task(task.plainTask)
+	if t.Status == "" {
+		t.Status = ActiveWorkflow.Initial()
+		if task.Done != nil && *task.Done {
+			t.Status = StatusDone
+		}
+	}
+	return nil
+}
+
+func (t Task) details() []string {
+	details := []string{}
+	if t.Parent != nil {
+		details = append(details, fmt.Sprintf("subtask of %d", *t.Parent))
+	}
+	if len(t.DependsOn) > 0 {
+		details = append(details, "depends on "+strings.ReplaceAll(strings.Trim(fmt.Sprint(t.DependsOn), "[]"), " ", ", "))
+	}
+	if t.Project != "" {
+		details = append(details, ProjectPrefix+t.Project)
+	}
+	for _, tag := range t.Tags {
+		details = append(details, TagPrefix+tag)
+	}
+	if t.Priority != PriorityNone {
+		details = append(details, "priority "+t.Priority.String())
+	}
+	if t.Estimate != nil {
+		details = append(details, "estimate "+t.Estimate.String())
+	}
+	if t.Due != nil {
+		details = append(details, "due "+t.Due.Format(DateLayout))
+	}
+	if t.Scheduled != nil {
+		details = append(details, "scheduled "+t.Scheduled.Format(DateLayout))
+	}
+	if t.Recurrence != nil {
+		details = append(details, "repeats "+t.Recurrence.String())
+	}
+	if len(t.Intervals) > 0 {
+		details = append(details, "logged "+FormatDuration(Logged(t, nil, nil, Now())))
+	}
+	if t.timerRunning() {
+		details = append(details, "timer running")
+	}
+	if t.ArchivedAt != nil {
+		details = append(details, "archived "+t.ArchivedAt.Format(DateLayout))
+	}
+	return details
+}
+
+// TaskList is the persisted unit of a store: the tasks themselves and the
+// high-water mark used to allocate the next ID. NextID only ever grows, so
+// IDs freed by Delete are never handed out again. Archived tasks are kept
+// apart from the active ones and never linked to them.
+type TaskList struct {
+	NextID   int    `yaml:"NextID"`
+	Tasks    []Task `yaml:"Tasks"`
+	Archived []Task `json:",omitempty" yaml:"Archived,omitempty" toml:",omitempty"`
+}
+
+func NewTaskList(tasks []Task, nextID int) TaskList {
+	for _, task := range tasks {
+		if task.ID >= nextID {
+			nextID = task.ID + 1
+		}
+	}
+	return TaskList{NextID: nextID, Tasks: tasks}
+}
+
+// Normalize raises NextID above the ids of the active and archived tasks.
+func (l TaskList) Normalize() TaskList {
+	l.NextID = NewTaskList(l.Archived, NewTaskList(l.Tasks, l.NextID).NextID).NextID
+	return l
+}
+
+func Validate(list TaskList) error {
+	all := append(slices.Clone(list.Tasks), list.Archived...)
+	seen := make(map[int]bool, len(all))
+	for _, task := range all {
+		if task.ID < 0 {
+			return fmt.Errorf("task %q has a negative id=%d", task.Description, task.ID)
+		}
+		if seen[task.ID] {
+			return fmt.Errorf("duplicate task id=%d", task.ID)
+		}
+		if task.ID >= list.NextID {
+			return fmt.Errorf("task id=%d is not below the next id=%d", task.ID, list.NextID)
+		}
+		if !slices.Contains(ActiveWorkflow.Statuses, task.Status) {
+			return fmt.Errorf("task id=%d has status %q unknown to the workflow", task.ID, task.Status)
+		}
+		if err := ValidateProject(task.Project); err != nil {
+			return fmt.Errorf("task id=%d: %w", task.ID, err)
+		}
+		for _, tag := range task.Tags {
+			if err := ValidateTag(tag); err != nil {
+				return fmt.Errorf("task id=%d: %w", task.ID, err)
+			}
+		}
+		seen[task.ID] = true
+	}
+	if err := validateArchive(list); err != nil {
+		return err
+	}
+	if err := validateIntervals(all); err != nil {
+		return err
+	}
+	for _, tasks := range [][]Task{list.Tasks, list.Archived} {
+		if err := validateHierarchy(tasks); err != nil {
+			return err
+		}
+		if err := validateDependencies(tasks); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,305 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vladiakimenko/go_project_planner/internal/logging"
+)
+
+// TimeOfDayLayout shows the end of an interval that ends on the day it started.
+const TimeOfDayLayout string = "15:04"
+
+// Interval is a stretch of work on a task; End is nil while its timer runs.
+type Interval struct {
+	Start time.Time  `yaml:"Start"`
+	End   *time.Time `json:",omitempty" yaml:"End,omitempty" toml:",omitempty"`
+}
+
+func (i Interval) Running() bool {
+	return i.End == nil
+}
+
+// Duration counts a running interval up to now.
+func (i Interval) Duration(now time.Time) time.Duration {
+	return i.within(nil, nil, now)
+}
+
+// within returns how much of the interval falls in [since, until).
+func (i Interval) within(since *time.Time, until *time.Time, now time.Time) time.Duration {
+	start, end := i.Start, now
+	if i.End != nil {
+		end = *i.End
+	}
+	if since != nil && start.Before(*since) {
+		start = *since
+	}
+	if until != nil && end.After(*until) {
+		end = *until
+	}
+	return max(end.Sub(start), 0)
+}
+
+func (i Interval) String() string {
+	end := "now"
+	if i.End != nil {
+		end = i.End.Format(TimeOfDayLayout)
+		if !SameDay(i.Start, *i.End) {
+			end = i.End.Format(AnnotationLayout)
+		}
+	}
+	return fmt.Sprintf("%s - %s", i.Start.Format(AnnotationLayout), end)
+}
+
+var ErrTimerRunning = errors.New("a timer is already running")
+
+// Running returns the task whose timer is running; there is at most one.
+func Running(tasks []Task) (Task, bool) {
+	for _, task := range tasks {
+		if task.timerRunning() {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+func (t Task) timerRunning() bool {
+	return slices.ContainsFunc(t.Intervals, Interval.Running)
+}
+
+// StartTimer opens an interval on task id, moving it to in-progress when the
+// workflow allows. Only one timer may run at a time, and never on a closed
+// task.
+func StartTimer(tasks []Task, id int) ([]Task, error) {
+	if running, ok := Running(tasks); ok {
+		return []Task{}, fmt.Errorf("%w on task id=%d, stop it first", ErrTimerRunning, running.ID)
+	}
+	for i, task := range tasks {
+		if task.ID == id {
+			if task.Status.Closed() {
+				return []Task{}, fmt.Errorf("task id=%d is %s, reopen it to track time", id, task.Status)
+			}
+			now := Now()
+			started := task
+			started.Intervals = append(slices.Clone(task.Intervals), Interval{Start: now})
+			if ActiveWorkflow.Allows(task.Status, StatusInProgress) {
+				started.Status = StatusInProgress
+			}
+			tasks[i] = touch(task, started, now)
+			return tasks, nil
+		}
+	}
+	logging.Logger.Error("Could not find a task with specified id", "id", id)
+	return []Task{}, fmt.Errorf("task with requested id=%d is missing", id)
+}
+
+// StopTimer closes the running interval and returns the task it belongs to.
+func StopTimer(tasks []Task) ([]Task, Task, error) {
+	running, ok := Running(tasks)
+	if !ok {
+		return []Task{}, Task{}, errors.New("no timer is running")
+	}
+	for i, task := range tasks {
+		if task.ID == running.ID {
+			now := Now()
+			tasks[i].Intervals = stopTimer(task.Intervals, now)
+			tasks[i].UpdatedAt = &now
+			return tasks, tasks[i], nil
+		}
+	}
+	return []Task{}, Task{}, errors.New("no timer is running")
+}
+
+func stopTimer(intervals []Interval, now time.Time) []Interval {
+	if !slices.ContainsFunc(intervals, Interval.Running) {
+		return intervals
+	}
+	intervals = slices.Clone(intervals)
+	for i := range intervals {
+		if intervals[i].Running() {
+			intervals[i].End = &now
+		}
+	}
+	return intervals
+}
+
+// LogTime records work done on task id outside of a timer as an interval
+// ending now.
+func LogTime(tasks []Task, id int, duration time.Duration) ([]Task, error) {
+	if duration <= 0 {
+		return []Task{}, fmt.Errorf("logged time has to be positive, got %s", duration)
+	}
+	for i, task := range tasks {
+		if task.ID == id {
+			now := Now()
+			interval := Interval{Start: now.Add(-duration), End: &now}
+			tasks[i].Intervals = append(slices.Clone(task.Intervals), interval)
+			tasks[i].UpdatedAt = &now
+			return tasks, nil
+		}
+	}
+	logging.Logger.Error("Could not find a task with specified id", "id", id)
+	return []Task{}, fmt.Errorf("task with requested id=%d is missing", id)
+}
+
+// Logged sums the work on task in [since, until); nil bounds are open.
+func Logged(task Task, since *time.Time, until *time.Time, now time.Time) time.Duration {
+	var total time.Duration
+	for _, interval := range task.Intervals {
+		total += interval.within(since, until, now)
+	}
+	return total
+}
+
+// ParseDuration reads a positive duration such as 1h30m, 90m or 1.5h.
+func ParseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(strings.ToLower(strings.TrimSpace(value)))
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected something like 1h30m or 45m", value)
+	}
+	return duration, nil
+}
+
+// FormatDuration rounds to whole minutes: 1h30m, 45m, 0m.
+func FormatDuration(duration time.Duration) string {
+	minutes := int(duration.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+	}
+}
+
+// ReportGroup decides what a time report sums the logged time by.
+type ReportGroup string
+
+const (
+	ReportByProject ReportGroup = "project"
+	ReportByTag     ReportGroup = "tag"
+	ReportByTask    ReportGroup = "task"
+	ReportByDay     ReportGroup = "day"
+)
+
+// ReportNone labels the time of tasks without a project or tags.
+const ReportNone string = "(none)"
+
+func ReportGroups() []ReportGroup {
+	return []ReportGroup{ReportByProject, ReportByTag, ReportByTask, ReportByDay}
+}
+
+func ParseReportGroup(value string) (ReportGroup, error) {
+	group := ReportGroup(strings.ToLower(value))
+	if !slices.Contains(ReportGroups(), group) {
+		return "", fmt.Errorf("invalid report grouping %q, expected project, tag, task or day", value)
+	}
+	return group, nil
+}
+
+type ReportLine struct {
+	Key      string
+	Duration time.Duration
+}
+
+// TimeReport sums the time logged in [since, until) by group. A task with
+// several tags counts towards each of them. Lines are sorted by key, tasks
+// keep their order and ReportNone goes last.
+func TimeReport(tasks []Task, group ReportGroup, since *time.Time, until *time.Time) []ReportLine {
+	now := Now()
+	totals := map[string]time.Duration{}
+	keys := []string{}
+	add := func(key string, duration time.Duration) {
+		if duration <= 0 {
+			return
+		}
+		if _, ok := totals[key]; !ok {
+			keys = append(keys, key)
+		}
+		totals[key] += duration
+	}
+	for _, task := range tasks {
+		switch group {
+		case ReportByDay:
+			// intervals are split at local midnight
+			for _, interval := range task.Intervals {
+				end := interval.Start.Add(interval.Duration(now))
+				for day := StartOfDay(interval.Start.Local()); day.Before(end); day = day.AddDate(0, 0, 1) {
+					from, to := latest(since, day), earliest(until, day.AddDate(0, 0, 1))
+					add(day.Format(DateLayout), interval.within(&from, &to, now))
+				}
+			}
+		case ReportByTask:
+			add(fmt.Sprintf("%d. %s", task.ID, task.Description), Logged(task, since, until, now))
+		case ReportByTag:
+			logged := Logged(task, since, until, now)
+			if len(task.Tags) == 0 {
+				add(ReportNone, logged)
+			}
+			for _, tag := range task.Tags {
+				add(TagPrefix+tag, logged)
+			}
+		default:
+			project := task.Project
+			if project == "" {
+				project = ReportNone
+			}
+			add(project, Logged(task, since, until, now))
+		}
+	}
+	if group != ReportByTask {
+		slices.SortFunc(keys, func(a, b string) int {
+			if (a == ReportNone) != (b == ReportNone) {
+				if a == ReportNone {
+					return 1
+				}
+				return -1
+			}
+			return strings.Compare(a, b)
+		})
+	}
+	lines := make([]ReportLine, len(keys))
+	for i, key := range keys {
+		lines[i] = ReportLine{Key: key, Duration: totals[key]}
+	}
+	return lines
+}
+
+func latest(bound *time.Time, value time.Time) time.Time {
+	if bound != nil && bound.After(value) {
+		return *bound
+	}
+	return value
+}
+
+func earliest(bound *time.Time, value time.Time) time.Time {
+	if bound != nil && bound.Before(value) {
+		return *bound
+	}
+	return value
+}
+
+// validateIntervals reports intervals ending before they start and more than
+// one running timer.
+func validateIntervals(tasks []Task) error {
+	running := -1
+	for _, task := range tasks {
+		for _, interval := range task.Intervals {
+			if interval.End != nil && interval.End.Before(interval.Start) {
+				return fmt.Errorf("task id=%d: interval ends before it starts", task.ID)
+			}
+			if !interval.Running() {
+				continue
+			}
+			if running >= 0 {
+				return fmt.Errorf("task id=%d: %w on task id=%d", task.ID, ErrTimerRunning, running)
+			}
+			running = task.ID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimer(t *testing.T) {
+	clock := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	Now = func() time.Time { return clock }
+	defer func() { Now = time.Now }()
+	tasks := []Task{
+		{ID: 0, Description: "Design", Status: StatusTodo},
+		{ID: 1, Description: "Schema", Status: StatusDone},
+		{ID: 2, Description: "API", Status: StatusTodo},
+	}
+
+	tasks, err := StartTimer(tasks, 0)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if tasks[0].Status != StatusInProgress || len(tasks[0].Intervals) != 1 || !tasks[0].Intervals[0].Running() {
+		t.Errorf("Test failed: timer not started: %+v", tasks[0])
+	}
+	if _, err := StartTimer(tasks, 2); !errors.Is(err, ErrTimerRunning) {
+		t.Errorf("Test failed: started a second timer: %v", err)
+	}
+
+	clock = clock.Add(90 * time.Minute)
+	tasks, stopped, err := StopTimer(tasks)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if stopped.ID != 0 || Logged(stopped, nil, nil, clock) != 90*time.Minute {
+		t.Errorf("Test failed: unexpected stopped task %+v", stopped)
+	}
+	if _, _, err := StopTimer(tasks); err == nil {
+		t.Error("Test failed: stopped a timer that is not running")
+	}
+	if _, err := StartTimer(tasks, 1); err == nil {
+		t.Error("Test failed: started a timer on a done task")
+	}
+
+	tasks, _ = StartTimer(tasks, 2)
+	clock = clock.Add(time.Hour)
+	list, err := Complete(NewTaskList(tasks, 0), 2)
+	if err != nil {
+		t.Fatalf("Test failed: Unexpected error: %v", err)
+	}
+	if _, ok := Running(list.Tasks); ok || Logged(list.Tasks[2], nil, nil, clock) != time.Hour {
+		t.Errorf("Test failed: completing did not stop the timer: %+v", list.Tasks[2])
+	}
+
+	tasks, err = LogTime(list.Tasks, 1, 45*time.Minute)
+	if err != nil || Logged(tasks[1], nil, nil, clock) != 45*time.Minute {
+		t.Errorf("Test failed: time not logged: %+v (%v)", tasks[1], err)
+	}
+	if _, err := LogTime(tasks, 9, time.Hour); err == nil {
+		t.Error("Test failed: logged time on a missing task")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      time.Duration
+		errorExpected bool
+	}{
+		{"1h30m", 90 * time.Minute, false},
+		{"45m", 45 * time.Minute, false},
+		{"1.5H", 90 * time.Minute, false},
+		{"0m", 0, true},
+		{"-1h", 0, true},
+		{"an hour", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if (err != nil) != tt.errorExpected {
+				t.Fatalf("Test failed: error occured %v, error expected %v", err, tt.errorExpected)
+			}
+			if got != tt.expected {
+				t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+	for duration, expected := range map[time.Duration]string{
+		0: "0m", 29 * time.Second: "0m", 45 * time.Minute: "45m", 2 * time.Hour: "2h", 150*time.Minute + 40*time.Second: "2h31m",
+	} {
+		if got := FormatDuration(duration); got != expected {
+			t.Errorf("Test failed: %v formatted as %s, expected %s", duration, got, expected)
+		}
+	}
+}
+
+func TestTimeReport(t *testing.T) {
+	Now = func() time.Time { return time.Date(2026, 10, 21, 12, 0, 0, 0, time.Local) }
+	defer func() { Now = time.Now }()
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+	}
+	interval := func(start time.Time, end time.Time) Interval {
+		return Interval{Start: start, End: &end}
+	}
+	tasks := []Task{
+		{ID: 0, Description: "Design", Project: "work", Tags: []string{"a", "b"}, Intervals: []Interval{
+			interval(at(18, 9), at(18, 11)),
+			interval(at(19, 23), at(20, 1)),
+		}},
+		{ID: 1, Description: "Groceries", Intervals: []Interval{interval(at(20, 10), at(20, 11))}},
+		{ID: 2, Description: "Review", Project: "work", Tags: []string{"a"}, Intervals: []Interval{{Start: at(21, 11)}}},
+		{ID: 3, Description: "Idle", Project: "home"},
+	}
+	since := at(19, 0)
+	tests := []struct {
+		group    ReportGroup
+		since    *time.Time
+		expected []ReportLine
+	}{
+		{ReportByProject, nil, []ReportLine{{"work", 5 * time.Hour}, {ReportNone, time.Hour}}},
+		{ReportByProject, &since, []ReportLine{{"work", 3 * time.Hour}, {ReportNone, time.Hour}}},
+		{ReportByTag, &since, []ReportLine{{"+a", 3 * time.Hour}, {"+b", 2 * time.Hour}, {ReportNone, time.Hour}}},
+		{ReportByTask, nil, []ReportLine{{"0. Design", 4 * time.Hour}, {"1. Groceries", time.Hour}, {"2. Review", time.Hour}}},
+		{ReportByDay, &since, []ReportLine{{"2026-10-19", time.Hour}, {"2026-10-20", 2 * time.Hour}, {"2026-10-21", time.Hour}}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.group), func(t *testing.T) {
+			got := TimeReport(tasks, tt.group, tt.since, nil)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Test failed: got %v, expected %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Test failed: got %v, expected %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateIntervals(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	tests := []struct {
+		name  string
+		tasks []Task
+	}{
+		{"ends before start", []Task{{ID: 0, Description: "A", Status: StatusTodo, Intervals: []Interval{{Start: start, End: &before}}}}},
+		{"two timers", []Task{
+			{ID: 0, Description: "A", Status: StatusTodo, Intervals: []Interval{{Start: start}}},
+			{ID: 1, Description: "B", Status: StatusTodo, Intervals: []Interval{{Start: start}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(NewTaskList(tt.tasks, 0)); err == nil {
+				t.Error("Test failed: accepted invalid intervals")
+			}
+		})
+	}
+}